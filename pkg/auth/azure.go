@@ -3,12 +3,15 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -17,16 +20,29 @@ import (
 	"github.com/heba920908/osdu-sdk-go/pkg/config"
 )
 
-// AzureProvider implements the AuthProvider interface for Azure Active Directory
+// AzureProvider implements the AuthProvider interface for Azure Active Directory. It is safe
+// for concurrent use, a token is fetched once and shared by the callers.
 type AzureProvider struct {
 	config       config.AuthSettings
 	credential   azcore.TokenCredential
+	mu           sync.Mutex
 	currentToken *Token
 	scopes       []string
 }
 
+// AzureProviderOptions holds optional settings for the Azure SDK credentials
+type AzureProviderOptions struct {
+	// ClientOptions are passed to every azidentity credential (transport, retries, cloud)
+	ClientOptions azcore.ClientOptions
+}
+
 // NewAzureProvider creates a new Azure authentication provider
 func NewAzureProvider(authConfig config.AuthSettings) (*AzureProvider, error) {
+	return NewAzureProviderWithOptions(authConfig, nil)
+}
+
+// NewAzureProviderWithOptions creates a new Azure authentication provider with custom Azure SDK client options
+func NewAzureProviderWithOptions(authConfig config.AuthSettings, options *AzureProviderOptions) (*AzureProvider, error) {
 	// Use scopes directly from the configuration
 	scopes := authConfig.Scopes
 	if len(scopes) == 0 {
@@ -34,41 +50,157 @@ func NewAzureProvider(authConfig config.AuthSettings) (*AzureProvider, error) {
 		scopes = []string{"https://graph.microsoft.com/.default"}
 	}
 
-	var credential azcore.TokenCredential
-	var err error
+	if options == nil {
+		options = &AzureProviderOptions{}
+	}
+
+	credential, err := newAzureCredential(authConfig, options.ClientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AzureProvider{
+		config:     authConfig,
+		credential: credential,
+		scopes:     scopes,
+	}, nil
+}
 
-	// Create Azure credential if SDK Auth is enabled or we have client credentials
+// azureCredentialMode resolves the credential mode, keeping sdkAuth and client secret detection for older configs
+func azureCredentialMode(authConfig config.AuthSettings) string {
+	if authConfig.AzureCredential != "" {
+		return authConfig.AzureCredential
+	}
 	if authConfig.SdkAuth {
+		return config.AzureCredentialDefault
+	}
+	if authConfig.ClientId != "" && authConfig.ClientSecret != "" && authConfig.TenantId != "" {
+		return config.AzureCredentialClientSecret
+	}
+	// No Azure SDK credential, OAuth2 fallback
+	return ""
+}
+
+// newAzureCredential creates the azidentity credential matching the configured mode
+func newAzureCredential(authConfig config.AuthSettings, clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	if authConfig.AuthorityHost != "" {
+		clientOptions.Cloud.ActiveDirectoryAuthorityHost = authConfig.AuthorityHost
+	}
+
+	switch mode := azureCredentialMode(authConfig); mode {
+	case "":
+		return nil, nil
+	case config.AzureCredentialDefault:
 		// Use default Azure credential (managed identity, Azure CLI, etc.)
-		credential, err = azidentity.NewDefaultAzureCredential(nil)
+		credential, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions:            clientOptions,
+			TenantID:                 authConfig.TenantId,
+			DisableInstanceDiscovery: authConfig.DisableInstanceDiscovery,
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create default Azure credential: %w", err)
 		}
-	} else if authConfig.ClientId != "" && authConfig.ClientSecret != "" && authConfig.TenantId != "" {
+		return credential, nil
+	case config.AzureCredentialClientSecret:
 		// Use client secret credential for service principal authentication
-		credential, err = azidentity.NewClientSecretCredential(
+		credential, err := azidentity.NewClientSecretCredential(
 			authConfig.TenantId,
 			authConfig.ClientId,
 			authConfig.ClientSecret,
-			nil,
+			&azidentity.ClientSecretCredentialOptions{
+				ClientOptions:            clientOptions,
+				DisableInstanceDiscovery: authConfig.DisableInstanceDiscovery,
+			},
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create client secret credential: %w", err)
 		}
-	}
+		return credential, nil
+	case config.AzureCredentialWorkloadIdentity:
+		// Empty values fall back to AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_FEDERATED_TOKEN_FILE
+		credential, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions:            clientOptions,
+			ClientID:                 authConfig.ClientId,
+			TenantID:                 authConfig.TenantId,
+			TokenFilePath:            authConfig.FederatedTokenFile,
+			DisableInstanceDiscovery: authConfig.DisableInstanceDiscovery,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workload identity credential: %w", err)
+		}
+		return credential, nil
+	case config.AzureCredentialManagedIdentity:
+		miOptions := &azidentity.ManagedIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+		}
+		switch {
+		case authConfig.ManagedIdentityClientId != "" && authConfig.ManagedIdentityResourceId != "":
+			return nil, errors.New("managedIdentityClientId and managedIdentityResourceId are mutually exclusive")
+		case authConfig.ManagedIdentityClientId != "":
+			miOptions.ID = azidentity.ClientID(authConfig.ManagedIdentityClientId)
+		case authConfig.ManagedIdentityResourceId != "":
+			miOptions.ID = azidentity.ResourceID(authConfig.ManagedIdentityResourceId)
+		}
 
-	return &AzureProvider{
-		config:     authConfig,
-		credential: credential,
-		scopes:     scopes,
-	}, nil
+		credential, err := azidentity.NewManagedIdentityCredential(miOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create managed identity credential: %w", err)
+		}
+		return credential, nil
+	case config.AzureCredentialClientCertificate:
+		certData, err := os.ReadFile(authConfig.CertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+
+		var password []byte
+		if authConfig.CertificatePassword != "" {
+			password = []byte(authConfig.CertificatePassword)
+		}
+		certs, key, err := azidentity.ParseCertificates(certData, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate %s: %w", authConfig.CertificatePath, err)
+		}
+
+		credential, err := azidentity.NewClientCertificateCredential(
+			authConfig.TenantId,
+			authConfig.ClientId,
+			certs,
+			key,
+			&azidentity.ClientCertificateCredentialOptions{
+				ClientOptions:            clientOptions,
+				DisableInstanceDiscovery: authConfig.DisableInstanceDiscovery,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client certificate credential: %w", err)
+		}
+		return credential, nil
+	case config.AzureCredentialAzureCLI:
+		credential, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: authConfig.TenantId,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure CLI credential: %w", err)
+		}
+		return credential, nil
+	default:
+		return nil, fmt.Errorf("unsupported azure credential: %s", mode)
+	}
 }
 
 // GetAccessToken retrieves an access token using Azure SDK or OAuth2
 func (p *AzureProvider) GetAccessToken(ctx context.Context) (*Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.getAccessToken(ctx)
+}
+
+// getAccessToken returns the current token or fetches a new one, p.mu must be held
+func (p *AzureProvider) getAccessToken(ctx context.Context) (*Token, error) {
 	slog.DebugContext(ctx, fmt.Sprintf("Azure Auth: Expire - %v", p.currentToken))
 
-	if p.IsTokenValid() {
+	if p.isTokenValid() {
 		slog.DebugContext(ctx, fmt.Sprintf("Azure token still active ... %s > %s", p.currentToken.ExpiresAt, time.Now()))
 		return p.currentToken, nil
 	}
@@ -89,7 +221,7 @@ func (p *AzureProvider) GetAccessToken(ctx context.Context) (*Token, error) {
 	return p.getTokenWithOAuth2(ctx)
 }
 
-// getTokenWithAzureSDK uses Azure SDK for authentication
+// getTokenWithAzureSDK uses Azure SDK for authentication, p.mu must be held
 func (p *AzureProvider) getTokenWithAzureSDK(ctx context.Context) (*Token, error) {
 	slog.InfoContext(ctx, "Azure - Using Azure SDK authentication")
 
@@ -124,7 +256,7 @@ func (p *AzureProvider) getTokenWithAzureSDK(ctx context.Context) (*Token, error
 	return token, nil
 }
 
-// getTokenWithOAuth2 uses OAuth2 flow for authentication (fallback)
+// getTokenWithOAuth2 uses OAuth2 flow for authentication (fallback), p.mu must be held
 func (p *AzureProvider) getTokenWithOAuth2(ctx context.Context) (*Token, error) {
 	slog.InfoContext(ctx, "Azure - Using OAuth2 flow authentication")
 
@@ -173,12 +305,8 @@ func (p *AzureProvider) getTokenWithOAuth2(ctx context.Context) (*Token, error)
 		return nil, fmt.Errorf("error while trying to parse token json body: %w", err)
 	}
 
-	// Set expiration time (fallback to 60 minutes if not provided)
-	if token.ExpiresIn > 0 {
-		token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
-	} else {
-		token.ExpiresAt = time.Now().Add(60 * time.Minute)
-	}
+	// Set expiration time (fallback to the exp claim, then 60 minutes if not provided)
+	setTokenExpiry(&token, 60*time.Minute)
 
 	// Store the token
 	p.currentToken = &token
@@ -189,6 +317,12 @@ func (p *AzureProvider) getTokenWithOAuth2(ctx context.Context) (*Token, error)
 
 // IsTokenValid checks if the current token is valid and not expired
 func (p *AzureProvider) IsTokenValid() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isTokenValid()
+}
+
+func (p *AzureProvider) isTokenValid() bool {
 	if p.currentToken == nil {
 		return false
 	}
@@ -197,6 +331,9 @@ func (p *AzureProvider) IsTokenValid() bool {
 
 // RefreshToken attempts to refresh the current token
 func (p *AzureProvider) RefreshToken(ctx context.Context) (*Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	// For Azure SDK, just request a new token (SDK handles refresh automatically)
 	if p.config.SdkAuth || p.credential != nil {
 		return p.getTokenWithAzureSDK(ctx)
@@ -221,7 +358,7 @@ func (p *AzureProvider) RefreshToken(ctx context.Context) (*Token, error) {
 	}
 
 	// Fall back to getting a new token
-	return p.GetAccessToken(ctx)
+	return p.getAccessToken(ctx)
 }
 
 // GetScopes returns the configured scopes for testing and debugging purposes
@@ -0,0 +1,300 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeTenantId = "00000000-0000-0000-0000-000000000001"
+
+// newFakeAAD starts a TLS server answering OpenID discovery and token requests for fakeTenantId.
+// check is called with the parsed token request form.
+func newFakeAAD(t *testing.T, accessToken string, check func(form map[string][]string)) (*httptest.Server, *auth.AzureProviderOptions) {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+			json.NewEncoder(w).Encode(map[string]string{
+				"authorization_endpoint": fmt.Sprintf("%s/%s/oauth2/v2.0/authorize", server.URL, fakeTenantId),
+				"token_endpoint":         fmt.Sprintf("%s/%s/oauth2/v2.0/token", server.URL, fakeTenantId),
+				"issuer":                 fmt.Sprintf("%s/%s/v2.0", server.URL, fakeTenantId),
+			})
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+			require.NoError(t, r.ParseForm())
+			if check != nil {
+				check(r.PostForm)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": accessToken,
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &auth.AzureProviderOptions{
+		ClientOptions: azcore.ClientOptions{Transport: server.Client()},
+	}
+}
+
+func TestAzureProvider_ClientSecretCredential(t *testing.T) {
+	server, options := newFakeAAD(t, "client-secret-token", func(form map[string][]string) {
+		assert.Equal(t, "client_credentials", form["grant_type"][0])
+		assert.Equal(t, "test-client-id", form["client_id"][0])
+		assert.Equal(t, "test-client-secret", form["client_secret"][0])
+	})
+
+	provider, err := auth.NewAzureProviderWithOptions(config.AuthSettings{
+		AzureCredential:          config.AzureCredentialClientSecret,
+		ClientId:                 "test-client-id",
+		ClientSecret:             "test-client-secret",
+		TenantId:                 fakeTenantId,
+		AuthorityHost:            server.URL,
+		DisableInstanceDiscovery: true,
+		Scopes:                   []string{"api://osdu-secret/.default"},
+	}, options)
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "client-secret-token", token.AccessToken)
+	assert.True(t, provider.IsTokenValid())
+}
+
+func TestAzureProvider_ConcurrentGetAccessToken(t *testing.T) {
+	server, options := newFakeAAD(t, "shared-token", nil)
+
+	provider, err := auth.NewAzureProviderWithOptions(config.AuthSettings{
+		AzureCredential:          config.AzureCredentialClientSecret,
+		ClientId:                 "test-client-id",
+		ClientSecret:             "test-client-secret",
+		TenantId:                 fakeTenantId,
+		AuthorityHost:            server.URL,
+		DisableInstanceDiscovery: true,
+		Scopes:                   []string{"api://osdu-secret/.default"},
+	}, options)
+	require.NoError(t, err)
+
+	// Run with -race: the provider is shared by the workers of the client
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provider.IsTokenValid()
+			token, err := provider.GetAccessToken(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "shared-token", token.AccessToken)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAzureProvider_WorkloadIdentityCredential(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "azure-identity-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("federated-service-account-token"), 0600))
+
+	server, options := newFakeAAD(t, "workload-identity-token", func(form map[string][]string) {
+		assert.Equal(t, "test-client-id", form["client_id"][0])
+		assert.Equal(t, "federated-service-account-token", form["client_assertion"][0])
+		assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", form["client_assertion_type"][0])
+	})
+
+	provider, err := auth.NewAzureProviderWithOptions(config.AuthSettings{
+		AzureCredential:          config.AzureCredentialWorkloadIdentity,
+		ClientId:                 "test-client-id",
+		TenantId:                 fakeTenantId,
+		FederatedTokenFile:       tokenFile,
+		AuthorityHost:            server.URL,
+		DisableInstanceDiscovery: true,
+		Scopes:                   []string{"api://osdu-workload/.default"},
+	}, options)
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "workload-identity-token", token.AccessToken)
+}
+
+func TestAzureProvider_ClientCertificateCredential(t *testing.T) {
+	certPath := writeTestCertificate(t)
+
+	server, options := newFakeAAD(t, "client-certificate-token", func(form map[string][]string) {
+		assert.Equal(t, "test-client-id", form["client_id"][0])
+		assert.NotEmpty(t, form["client_assertion"][0])
+		assert.Empty(t, form["client_secret"])
+	})
+
+	provider, err := auth.NewAzureProviderWithOptions(config.AuthSettings{
+		AzureCredential:          config.AzureCredentialClientCertificate,
+		ClientId:                 "test-client-id",
+		TenantId:                 fakeTenantId,
+		CertificatePath:          certPath,
+		AuthorityHost:            server.URL,
+		DisableInstanceDiscovery: true,
+		Scopes:                   []string{"api://osdu-certificate/.default"},
+	}, options)
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "client-certificate-token", token.AccessToken)
+}
+
+func TestAzureProvider_ClientCertificateMissingFile(t *testing.T) {
+	_, err := auth.NewAzureProvider(config.AuthSettings{
+		AzureCredential: config.AzureCredentialClientCertificate,
+		ClientId:        "test-client-id",
+		TenantId:        fakeTenantId,
+		CertificatePath: filepath.Join(t.TempDir(), "missing.pem"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read client certificate")
+}
+
+func TestAzureProvider_ManagedIdentityCredential(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   config.AuthSettings
+		queryKey   string
+		queryValue string
+	}{
+		{
+			name: "user-assigned by client id",
+			settings: config.AuthSettings{
+				ManagedIdentityClientId: "mi-client-id",
+				Scopes:                  []string{"api://osdu-mi-client/.default"},
+			},
+			queryKey:   "client_id",
+			queryValue: "mi-client-id",
+		},
+		{
+			name: "user-assigned by resource id",
+			settings: config.AuthSettings{
+				ManagedIdentityResourceId: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/osdu",
+				Scopes:                    []string{"api://osdu-mi-resource/.default"},
+			},
+			queryKey:   "mi_res_id",
+			queryValue: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/osdu",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fake managed identity endpoint, selected by azidentity through IDENTITY_ENDPOINT/IDENTITY_HEADER
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "fake-identity-header", r.Header.Get("X-IDENTITY-HEADER"))
+				assert.Equal(t, tt.queryValue, r.URL.Query().Get(tt.queryKey))
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"access_token": "managed-identity-token",
+					"token_type":   "Bearer",
+					"expires_on":   fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()),
+					"resource":     r.URL.Query().Get("resource"),
+				})
+			}))
+			defer server.Close()
+
+			t.Setenv("IDENTITY_ENDPOINT", server.URL)
+			t.Setenv("IDENTITY_HEADER", "fake-identity-header")
+
+			settings := tt.settings
+			settings.AzureCredential = config.AzureCredentialManagedIdentity
+			provider, err := auth.NewAzureProvider(settings)
+			require.NoError(t, err)
+
+			token, err := provider.GetAccessToken(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "managed-identity-token", token.AccessToken)
+		})
+	}
+}
+
+func TestAzureProvider_ManagedIdentityConflictingIds(t *testing.T) {
+	_, err := auth.NewAzureProvider(config.AuthSettings{
+		AzureCredential:           config.AzureCredentialManagedIdentity,
+		ManagedIdentityClientId:   "mi-client-id",
+		ManagedIdentityResourceId: "/subscriptions/sub/resourceGroups/rg",
+	})
+	assert.Error(t, err)
+}
+
+func TestAzureProvider_AzureCLICredential(t *testing.T) {
+	// Fake az binary printing a token the way `az account get-access-token -o json` does
+	binDir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\necho '{\"accessToken\":\"azure-cli-token\",\"expires_on\":%d,\"tokenType\":\"Bearer\"}'\n",
+		time.Now().Add(time.Hour).Unix())
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "az"), []byte(script), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	provider, err := auth.NewAzureProvider(config.AuthSettings{
+		AzureCredential: config.AzureCredentialAzureCLI,
+		Scopes:          []string{"api://osdu-cli/.default"},
+	})
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "azure-cli-token", token.AccessToken)
+}
+
+func TestAzureProvider_UnknownCredential(t *testing.T) {
+	_, err := auth.NewAzureProvider(config.AuthSettings{
+		AzureCredential: "kerberos",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported azure credential")
+}
+
+// writeTestCertificate writes a self-signed certificate and its private key as a single PEM file
+func writeTestCertificate(t *testing.T) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "osdu-sdk-go-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	var data []byte
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...)
+
+	certPath := filepath.Join(t.TempDir(), "client.pem")
+	require.NoError(t, os.WriteFile(certPath, data, 0600))
+	return certPath
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// ChainProvider implements the AuthProvider interface by trying several providers in order.
+// The first provider that produces a token wins and is used first on subsequent calls.
+type ChainProvider struct {
+	providers []AuthProvider
+	names     []string
+	mu        sync.Mutex
+	active    int
+}
+
+// NewChainProvider creates a chain provider from an ordered list of providers.
+// names is optional and only used to label errors and logs.
+func NewChainProvider(providers []AuthProvider, names []string) (*ChainProvider, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("chain provider requires at least one provider")
+	}
+
+	labels := make([]string, len(providers))
+	for i := range providers {
+		if i < len(names) && names[i] != "" {
+			labels[i] = names[i]
+		} else {
+			labels[i] = fmt.Sprintf("provider[%d]", i)
+		}
+	}
+
+	return &ChainProvider{
+		providers: providers,
+		names:     labels,
+		active:    -1,
+	}, nil
+}
+
+// GetAccessToken returns a token from the provider that last succeeded, or walks the chain
+func (p *ChainProvider) GetAccessToken(ctx context.Context) (*Token, error) {
+	return p.getToken(ctx, func(provider AuthProvider) (*Token, error) {
+		return provider.GetAccessToken(ctx)
+	})
+}
+
+// IsTokenValid checks if the winning provider holds a valid token
+func (p *ChainProvider) IsTokenValid() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active < 0 {
+		return false
+	}
+	return p.providers[p.active].IsTokenValid()
+}
+
+// RefreshToken refreshes the token through the winning provider, or walks the chain
+func (p *ChainProvider) RefreshToken(ctx context.Context) (*Token, error) {
+	return p.getToken(ctx, func(provider AuthProvider) (*Token, error) {
+		return provider.RefreshToken(ctx)
+	})
+}
+
+// Active returns the name and provider that produced the last token.
+// ok is false when no provider has succeeded yet.
+func (p *ChainProvider) Active() (name string, provider AuthProvider, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active < 0 {
+		return "", nil, false
+	}
+	return p.names[p.active], p.providers[p.active], true
+}
+
+func (p *ChainProvider) getToken(ctx context.Context, fetch func(AuthProvider) (*Token, error)) (*Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+
+	// Prefer the provider that succeeded last time
+	if p.active >= 0 {
+		token, err := fetch(p.providers[p.active])
+		if err == nil {
+			return token, nil
+		}
+		slog.WarnContext(ctx, fmt.Sprintf("Chain - %s failed, walking chain again: %s", p.names[p.active], err))
+		errs = append(errs, fmt.Errorf("%s: %w", p.names[p.active], err))
+	}
+
+	for i, provider := range p.providers {
+		if i == p.active {
+			continue
+		}
+
+		token, err := fetch(provider)
+		if err != nil {
+			slog.DebugContext(ctx, fmt.Sprintf("Chain - %s failed: %s", p.names[i], err))
+			errs = append(errs, fmt.Errorf("%s: %w", p.names[i], err))
+			continue
+		}
+		if token == nil || token.AccessToken == "" {
+			errs = append(errs, fmt.Errorf("%s: empty access token", p.names[i]))
+			continue
+		}
+
+		slog.InfoContext(ctx, fmt.Sprintf("Chain - using %s", p.names[i]))
+		p.active = i
+		return token, nil
+	}
+
+	p.active = -1
+	return nil, fmt.Errorf("no provider in chain produced a token: %w", errors.Join(errs...))
+}
@@ -0,0 +1,134 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenServer(t *testing.T, accessToken string, calls *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newFailingServer(t *testing.T, calls *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func openIDSettings(tokenUrl string) config.AuthSettings {
+	return config.AuthSettings{
+		ClientId:  "test-client-id",
+		TokenUrl:  tokenUrl,
+		GrantType: "client_credentials",
+		Scopes:    []string{"openid"},
+	}
+}
+
+func TestChainProvider_FirstSuccessWins(t *testing.T) {
+	var failCalls, okCalls int
+	failing := newFailingServer(t, &failCalls)
+	working := newTokenServer(t, "chain-access-token", &okCalls)
+
+	provider, err := auth.NewChainProvider([]auth.AuthProvider{
+		auth.NewOpenIDProvider(openIDSettings(failing.URL)),
+		auth.NewOpenIDProvider(openIDSettings(working.URL)),
+	}, []string{"failing", "working"})
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "chain-access-token", token.AccessToken)
+	assert.True(t, provider.IsTokenValid())
+
+	name, _, ok := provider.Active()
+	assert.True(t, ok)
+	assert.Equal(t, "working", name)
+
+	// The winner is remembered, the failing provider is not retried
+	_, err = provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, failCalls)
+	assert.Equal(t, 1, okCalls)
+}
+
+func TestChainProvider_AggregatedError(t *testing.T) {
+	var firstCalls, secondCalls int
+	first := newFailingServer(t, &firstCalls)
+	second := newFailingServer(t, &secondCalls)
+
+	provider, err := auth.NewChainProvider([]auth.AuthProvider{
+		auth.NewOpenIDProvider(openIDSettings(first.URL)),
+		auth.NewOpenIDProvider(openIDSettings(second.URL)),
+	}, []string{"first", "second"})
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	assert.Nil(t, token)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "first: unexpected auth code: 401")
+	assert.Contains(t, err.Error(), "second: unexpected auth code: 401")
+	assert.False(t, provider.IsTokenValid())
+
+	_, _, ok := provider.Active()
+	assert.False(t, ok)
+}
+
+func TestChainProvider_Empty(t *testing.T) {
+	_, err := auth.NewChainProvider(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestChainProvider_FromFactory(t *testing.T) {
+	var calls int
+	working := newTokenServer(t, "factory-chain-token", &calls)
+
+	osduConfig := config.OsduClient{
+		Provider: "chain",
+		AuthSettings: config.AuthSettings{
+			Chain: []config.ChainedProvider{
+				{Provider: "openid", AuthSettings: openIDSettings("http://invalid-url-that-should-not-exist.local")},
+				{Provider: "openid", AuthSettings: openIDSettings(working.URL)},
+			},
+		},
+	}
+
+	provider, err := auth.NewProviderFactory().GetProviderFromConfig(osduConfig)
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "factory-chain-token", token.AccessToken)
+
+	chain, ok := provider.(*auth.ChainProvider)
+	require.True(t, ok)
+	name, _, _ := chain.Active()
+	assert.Equal(t, "openid[1]", name)
+}
+
+func TestChainProvider_FactoryRequiresEntries(t *testing.T) {
+	_, err := auth.NewProviderFactory().CreateProvider(auth.ProviderTypeChain, config.AuthSettings{})
+	assert.Error(t, err)
+}
@@ -21,6 +21,8 @@ func (f *ProviderFactory) CreateProvider(providerType ProviderType, authConfig c
 		return NewOpenIDProvider(authConfig), nil
 	case ProviderTypeAzure:
 		return NewAzureProvider(authConfig)
+	case ProviderTypeChain:
+		return f.createChainProvider(authConfig.Chain)
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
@@ -35,3 +37,28 @@ func (f *ProviderFactory) GetProviderFromConfig(osduConfig config.OsduClient) (A
 
 	return f.CreateProvider(providerType, osduConfig.AuthSettings)
 }
+
+// createChainProvider creates every provider of the chain in order
+func (f *ProviderFactory) createChainProvider(chain []config.ChainedProvider) (AuthProvider, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("provider type %s requires at least one entry in auth.chain", ProviderTypeChain)
+	}
+
+	providers := make([]AuthProvider, 0, len(chain))
+	names := make([]string, 0, len(chain))
+	for i, entry := range chain {
+		providerType := ProviderType(entry.Provider)
+		if providerType == "" {
+			providerType = ProviderTypeOpenID
+		}
+
+		provider, err := f.CreateProvider(providerType, entry.AuthSettings)
+		if err != nil {
+			return nil, fmt.Errorf("chain entry %d (%s): %w", i, providerType, err)
+		}
+		providers = append(providers, provider)
+		names = append(names, fmt.Sprintf("%s[%d]", providerType, i))
+	}
+
+	return NewChainProvider(providers, names)
+}
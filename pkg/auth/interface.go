@@ -75,4 +75,5 @@ type ProviderType string
 const (
 	ProviderTypeOpenID ProviderType = "openid"
 	ProviderTypeAzure  ProviderType = "azure"
+	ProviderTypeChain  ProviderType = "chain"
 )
@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default JWKS cache timings
+const (
+	DefaultJWKSTTL                = 24 * time.Hour
+	DefaultJWKSMinRefreshInterval = 1 * time.Minute
+)
+
+// JSONWebKey is a single key of a JWKS document (RSA and EC keys are supported)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the document served by the issuer jwks_uri
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKSCache fetches and caches the signing keys of an issuer.
+// Keys are refreshed after TTL, and immediately when a token references an unknown kid
+// (key rotation), at most once per MinRefreshInterval.
+type JWKSCache struct {
+	jwksUrl            string
+	httpClient         *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKSCache creates a key cache for the given jwks_uri
+func NewJWKSCache(jwksUrl string) *JWKSCache {
+	return &JWKSCache{
+		jwksUrl:            jwksUrl,
+		httpClient:         &http.Client{Timeout: 30 * time.Second},
+		ttl:                DefaultJWKSTTL,
+		minRefreshInterval: DefaultJWKSMinRefreshInterval,
+		keys:               map[string]crypto.PublicKey{},
+	}
+}
+
+// NewJWKSCacheFromIssuer discovers the jwks_uri from the issuer OpenID configuration
+func NewJWKSCacheFromIssuer(ctx context.Context, issuer string) (*JWKSCache, error) {
+	discoveryUrl := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching openid configuration: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected openid configuration code: %d", res.StatusCode)
+	}
+
+	var discovery struct {
+		JwksUri string `json:"jwks_uri"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("error while parsing openid configuration: %w", err)
+	}
+	if discovery.JwksUri == "" {
+		return nil, fmt.Errorf("openid configuration of %s has no jwks_uri", issuer)
+	}
+
+	return NewJWKSCache(discovery.JwksUri), nil
+}
+
+// WithTTL sets how long fetched keys are trusted before a refresh
+func (c *JWKSCache) WithTTL(ttl time.Duration) *JWKSCache {
+	c.ttl = ttl
+	return c
+}
+
+// WithMinRefreshInterval limits how often an unknown kid triggers a refresh
+func (c *JWKSCache) WithMinRefreshInterval(interval time.Duration) *JWKSCache {
+	c.minRefreshInterval = interval
+	return c
+}
+
+// WithHTTPClient sets the client used to fetch the key set
+func (c *JWKSCache) WithHTTPClient(client *http.Client) *JWKSCache {
+	c.httpClient = client
+	return c
+}
+
+// Key returns the public key for kid, refreshing the key set when needed
+func (c *JWKSCache) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stale := time.Since(c.fetchedAt) > c.ttl
+	key, found := c.keys[kid]
+	if found && !stale {
+		return key, nil
+	}
+
+	// Unknown kid: refresh unless we just did, to avoid hammering the issuer with bogus tokens
+	if stale || time.Since(c.fetchedAt) > c.minRefreshInterval {
+		if err := c.refresh(ctx); err != nil {
+			if found {
+				slog.WarnContext(ctx, fmt.Sprintf("JWKS refresh failed, using cached key %s: %s", kid, err))
+				return key, nil
+			}
+			return nil, err
+		}
+		key, found = c.keys[kid]
+	}
+
+	if !found {
+		return nil, fmt.Errorf("signing key %q not found in %s", kid, c.jwksUrl)
+	}
+	return key, nil
+}
+
+// Verify checks the token signature against the issuer keys, then exp and nbf, and returns its claims
+func (c *JWKSCache) Verify(ctx context.Context, accessToken string) (*Claims, error) {
+	jwt, err := ParseJWT(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.Key(ctx, jwt.Header.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jwt.VerifySignature(key); err != nil {
+		return nil, err
+	}
+
+	if err := jwt.Claims.ValidateTimes(time.Now(), time.Minute); err != nil {
+		return nil, err
+	}
+
+	return &jwt.Claims, nil
+}
+
+func (c *JWKSCache) refresh(ctx context.Context) error {
+	slog.DebugContext(ctx, fmt.Sprintf("Fetching JWKS %s", c.jwksUrl))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.jwksUrl, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while fetching jwks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected jwks code: %d", res.StatusCode)
+	}
+
+	var set JSONWebKeySet
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return fmt.Errorf("error while parsing jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			slog.WarnContext(ctx, fmt.Sprintf("Skipping JWKS key %s: %s", jwk.KeyId, err))
+			continue
+		}
+		keys[jwk.KeyId] = key
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
+
+// PublicKey converts the JWK into an *rsa.PublicKey or *ecdsa.PublicKey
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve: %s", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.KeyType)
+	}
+}
+
+// VerifySignature checks the JWT signature with the given public key (RS256/384/512, PS256/384/512, ES256/384/512)
+func (t *JWT) VerifySignature(key crypto.PublicKey) error {
+	alg := t.Header.Algorithm
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported jwt algorithm: %s", alg)
+	}
+
+	var hashFunc crypto.Hash
+	var hasher hash.Hash
+	switch alg[2:] {
+	case "256":
+		hashFunc, hasher = crypto.SHA256, sha256.New()
+	case "384":
+		hashFunc, hasher = crypto.SHA384, sha512.New384()
+	case "512":
+		hashFunc, hasher = crypto.SHA512, sha512.New()
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %s", alg)
+	}
+	hasher.Write([]byte(t.signed))
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s requires an RSA key", alg)
+		}
+		var err error
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(rsaKey, hashFunc, digest, t.signature)
+		} else {
+			err = rsa.VerifyPSS(rsaKey, hashFunc, digest, t.signature, nil)
+		}
+		if err != nil {
+			return errors.New("invalid jwt signature")
+		}
+		return nil
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s requires an EC key", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return errors.New("invalid jwt signature")
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid jwt signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %s", alg)
+	}
+}
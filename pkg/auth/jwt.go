@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTHeader represents the JOSE header of a JWT
+type JWTHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyId     string `json:"kid,omitempty"`
+}
+
+// Claims holds the typed claims commonly found in OSDU access tokens (Keycloak and Entra ID)
+type Claims struct {
+	Issuer            string
+	Subject           string
+	Audience          []string
+	ExpiresAt         time.Time
+	IssuedAt          time.Time
+	NotBefore         time.Time
+	AuthorizedParty   string // azp
+	AppId             string // appid (Entra ID v1 tokens)
+	ObjectId          string // oid
+	TenantId          string // tid
+	Email             string
+	PreferredUsername string
+	Upn               string
+	Name              string
+	Scopes            []string // scp or scope, space separated
+	Roles             []string
+	// Raw holds every claim of the payload, including the ones not mapped above
+	Raw map[string]interface{}
+}
+
+// JWT is a parsed, not yet verified, JSON Web Token
+type JWT struct {
+	Header    JWTHeader
+	Claims    Claims
+	signed    string
+	signature []byte
+}
+
+// ParseJWT decodes a compact serialized JWT without verifying its signature
+func ParseJWT(raw string) (*JWT, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed jwt: expected 3 segments, got %d", len(parts))
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt header: %w", err)
+	}
+	var header JWTHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("malformed jwt header: %w", err)
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt payload: %w", err)
+	}
+	claims, err := parseClaims(payloadBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt signature: %w", err)
+	}
+
+	return &JWT{
+		Header:    header,
+		Claims:    *claims,
+		signed:    parts[0] + "." + parts[1],
+		signature: signature,
+	}, nil
+}
+
+// ParseClaims decodes the claims of an access token without verifying its signature
+func ParseClaims(accessToken string) (*Claims, error) {
+	jwt, err := ParseJWT(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &jwt.Claims, nil
+}
+
+// Claims decodes the claims of the access token without verifying its signature
+func (t *Token) Claims() (*Claims, error) {
+	return ParseClaims(t.AccessToken)
+}
+
+func parseClaims(payload []byte) (*Claims, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("malformed jwt payload: %w", err)
+	}
+
+	c := &Claims{
+		Issuer:            claimString(raw, "iss"),
+		Subject:           claimString(raw, "sub"),
+		Audience:          claimStrings(raw, "aud"),
+		ExpiresAt:         claimTime(raw, "exp"),
+		IssuedAt:          claimTime(raw, "iat"),
+		NotBefore:         claimTime(raw, "nbf"),
+		AuthorizedParty:   claimString(raw, "azp"),
+		AppId:             claimString(raw, "appid"),
+		ObjectId:          claimString(raw, "oid"),
+		TenantId:          claimString(raw, "tid"),
+		Email:             claimString(raw, "email"),
+		PreferredUsername: claimString(raw, "preferred_username"),
+		Upn:               claimString(raw, "upn"),
+		Name:              claimString(raw, "name"),
+		Roles:             claimStrings(raw, "roles"),
+		Raw:               raw,
+	}
+
+	scope := claimString(raw, "scp")
+	if scope == "" {
+		scope = claimString(raw, "scope")
+	}
+	c.Scopes = strings.Fields(scope)
+
+	return c, nil
+}
+
+// Identity returns the effective identity of the caller, the way OSDU services resolve it:
+// email, then preferred_username, upn, oid, azp/appid and finally sub
+func (c *Claims) Identity() string {
+	for _, candidate := range []string{c.Email, c.PreferredUsername, c.Upn, c.ObjectId, c.AuthorizedParty, c.AppId, c.Subject} {
+		if candidate != "" {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// HasAudience reports whether aud contains the given audience
+func (c *Claims) HasAudience(audience string) bool {
+	for _, aud := range c.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTimes checks exp and nbf against now, allowing the given clock skew
+func (c *Claims) ValidateTimes(now time.Time, leeway time.Duration) error {
+	if !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt.Add(leeway)) {
+		return fmt.Errorf("token expired at %s", c.ExpiresAt.Format(time.RFC3339))
+	}
+	if !c.NotBefore.IsZero() && now.Add(leeway).Before(c.NotBefore) {
+		return fmt.Errorf("token not valid before %s", c.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// String summarizes the claims useful when troubleshooting authorization failures
+func (c *Claims) String() string {
+	return fmt.Sprintf("identity=%s aud=%s azp=%s oid=%s iss=%s exp=%s",
+		c.Identity(),
+		strings.Join(c.Audience, ","),
+		c.AuthorizedParty,
+		c.ObjectId,
+		c.Issuer,
+		c.ExpiresAt.Format(time.RFC3339))
+}
+
+func claimString(raw map[string]interface{}, key string) string {
+	if v, ok := raw[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// claimStrings accepts both a single string and an array of strings
+func claimStrings(raw map[string]interface{}, key string) []string {
+	switch v := raw[key].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func claimTime(raw map[string]interface{}, key string) time.Time {
+	if v, ok := raw[key].(float64); ok {
+		return time.Unix(int64(v), 0)
+	}
+	return time.Time{}
+}
+
+// setTokenExpiry sets ExpiresAt from expires_in, then from the exp claim, and finally from the fallback
+func setTokenExpiry(token *Token, fallback time.Duration) {
+	if token.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+		return
+	}
+
+	if claims, err := token.Claims(); err == nil && !claims.ExpiresAt.IsZero() {
+		token.ExpiresAt = claims.ExpiresAt
+		token.ExpiresIn = int(time.Until(claims.ExpiresAt).Seconds())
+		return
+	}
+
+	token.ExpiresAt = time.Now().Add(fallback)
+}
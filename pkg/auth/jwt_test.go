@@ -0,0 +1,299 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + b64(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signed + "." + b64(signature)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) auth.JSONWebKey {
+	return auth.JSONWebKey{
+		KeyType: "RSA",
+		KeyId:   kid,
+		Use:     "sig",
+		N:       b64(key.N.Bytes()),
+		E:       b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// jwksServer serves a mutable key set and counts fetches
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []auth.JSONWebKey
+	fetches int
+}
+
+func (s *jwksServer) setKeys(keys ...auth.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) start(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"jwks_uri": server.URL + "/keys"})
+		case "/keys":
+			s.fetches++
+			json.NewEncoder(w).Encode(auth.JSONWebKeySet{Keys: s.keys})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseClaims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	accessToken := signRS256(t, key, "kid-1", map[string]interface{}{
+		"iss":                "https://keycloak/realms/osdu",
+		"sub":                "5f1c",
+		"aud":                []string{"osdu", "account"},
+		"exp":                exp,
+		"azp":                "datafier",
+		"oid":                "object-id",
+		"preferred_username": "service-account-datafier",
+		"email":              "datafier@service.local",
+		"scope":              "openid email",
+	})
+
+	token := auth.Token{AccessToken: accessToken}
+	claims, err := token.Claims()
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://keycloak/realms/osdu", claims.Issuer)
+	assert.Equal(t, []string{"osdu", "account"}, claims.Audience)
+	assert.True(t, claims.HasAudience("osdu"))
+	assert.Equal(t, exp, claims.ExpiresAt.Unix())
+	assert.Equal(t, "datafier", claims.AuthorizedParty)
+	assert.Equal(t, "object-id", claims.ObjectId)
+	assert.Equal(t, []string{"openid", "email"}, claims.Scopes)
+	assert.Equal(t, "datafier@service.local", claims.Identity())
+	assert.NoError(t, claims.ValidateTimes(time.Now(), 0))
+	assert.Contains(t, claims.String(), "identity=datafier@service.local")
+}
+
+func TestClaimsIdentityPrecedence(t *testing.T) {
+	assert.Equal(t, "user@upn", (&auth.Claims{Upn: "user@upn", ObjectId: "oid", Subject: "sub"}).Identity())
+	assert.Equal(t, "oid", (&auth.Claims{ObjectId: "oid", Subject: "sub"}).Identity())
+	assert.Equal(t, "sub", (&auth.Claims{Subject: "sub"}).Identity())
+}
+
+func TestParseClaims_Malformed(t *testing.T) {
+	_, err := auth.ParseClaims("not-a-jwt")
+	assert.Error(t, err)
+
+	_, err = auth.ParseClaims("a.b.c")
+	assert.Error(t, err)
+}
+
+func TestJWKSCache_VerifyAndRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := &jwksServer{}
+	jwks.setKeys(rsaJWK("old", oldKey))
+	server := jwks.start(t)
+
+	cache, err := auth.NewJWKSCacheFromIssuer(context.Background(), server.URL)
+	require.NoError(t, err)
+	cache.WithMinRefreshInterval(0)
+
+	claims := map[string]interface{}{"email": "admin@example.com", "exp": time.Now().Add(time.Hour).Unix()}
+
+	verified, err := cache.Verify(context.Background(), signRS256(t, oldKey, "old", claims))
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", verified.Identity())
+	assert.Equal(t, 1, jwks.fetches)
+
+	// Cached key is reused
+	_, err = cache.Verify(context.Background(), signRS256(t, oldKey, "old", claims))
+	require.NoError(t, err)
+	assert.Equal(t, 1, jwks.fetches)
+
+	// The issuer rotates its key, an unknown kid triggers a refresh
+	jwks.setKeys(rsaJWK("old", oldKey), rsaJWK("new", newKey))
+	_, err = cache.Verify(context.Background(), signRS256(t, newKey, "new", claims))
+	require.NoError(t, err)
+	assert.Equal(t, 2, jwks.fetches)
+
+	// Signed by the wrong key
+	_, err = cache.Verify(context.Background(), signRS256(t, newKey, "old", claims))
+	assert.ErrorContains(t, err, "invalid jwt signature")
+
+	// Unknown key after refresh
+	_, err = cache.Verify(context.Background(), signRS256(t, newKey, "missing", claims))
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestJWKSCache_RefreshRateLimited(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := &jwksServer{}
+	jwks.setKeys(rsaJWK("kid-1", key))
+	server := jwks.start(t)
+
+	cache := auth.NewJWKSCache(server.URL + "/keys").WithMinRefreshInterval(time.Hour)
+	claims := map[string]interface{}{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()}
+
+	_, err = cache.Verify(context.Background(), signRS256(t, key, "kid-1", claims))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = cache.Verify(context.Background(), signRS256(t, key, "bogus", claims))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, jwks.fetches)
+}
+
+func TestJWKSCache_ExpiredToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := &jwksServer{}
+	jwks.setKeys(rsaJWK("kid-1", key))
+	server := jwks.start(t)
+
+	cache := auth.NewJWKSCache(server.URL + "/keys")
+	_, err = cache.Verify(context.Background(), signRS256(t, key, "kid-1", map[string]interface{}{
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}))
+	assert.ErrorContains(t, err, "token expired")
+}
+
+func TestJWKSCache_ECKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks := &jwksServer{}
+	jwks.setKeys(auth.JSONWebKey{
+		KeyType: "EC",
+		KeyId:   "ec-1",
+		Curve:   "P-256",
+		X:       b64(key.X.FillBytes(make([]byte, 32))),
+		Y:       b64(key.Y.FillBytes(make([]byte, 32))),
+	})
+	server := jwks.start(t)
+
+	cache := auth.NewJWKSCache(server.URL + "/keys")
+	claims, err := cache.Verify(context.Background(), signES256(t, key, "ec-1", map[string]interface{}{
+		"oid": "ec-object-id",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "ec-object-id", claims.Identity())
+}
+
+func TestOpenIDProvider_ExpiryFromClaims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	exp := time.Now().Add(30 * time.Minute).Unix()
+	accessToken := signRS256(t, key, "kid-1", map[string]interface{}{"sub": "datafier", "exp": exp})
+
+	// Token endpoint omitting expires_in
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+		})
+	}))
+	defer server.Close()
+
+	provider := auth.NewOpenIDProvider(config.AuthSettings{
+		ClientId:  "test-client-id",
+		TokenUrl:  server.URL,
+		GrantType: "client_credentials",
+	})
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, exp, token.ExpiresAt.Unix())
+	assert.True(t, provider.IsTokenValid())
+}
+
+func TestAzureProvider_OAuth2ExpiryFromClaims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	exp := time.Now().Add(10 * time.Minute).Unix()
+	accessToken := signRS256(t, key, "kid-1", map[string]interface{}{"oid": "azure-oid", "exp": exp})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"` + accessToken + `","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	provider, err := auth.NewAzureProvider(config.AuthSettings{
+		ClientId:  "test-client-id",
+		TokenUrl:  server.URL,
+		GrantType: "client_credentials",
+	})
+	require.NoError(t, err)
+
+	token, err := provider.GetAccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, exp, token.ExpiresAt.Unix())
+	assert.True(t, strings.HasPrefix(token.AccessToken, "ey"))
+}
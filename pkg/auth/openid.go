@@ -9,14 +9,17 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/heba920908/osdu-sdk-go/pkg/config"
 )
 
-// OpenIDProvider implements the AuthProvider interface for OpenID Connect/OAuth2
+// OpenIDProvider implements the AuthProvider interface for OpenID Connect/OAuth2. It is safe
+// for concurrent use, a token is fetched once and shared by the callers.
 type OpenIDProvider struct {
 	config       config.AuthSettings
+	mu           sync.Mutex
 	currentToken *Token
 }
 
@@ -29,7 +32,14 @@ func NewOpenIDProvider(authConfig config.AuthSettings) *OpenIDProvider {
 
 // GetAccessToken retrieves an access token using OpenID Connect/OAuth2
 func (p *OpenIDProvider) GetAccessToken(ctx context.Context) (*Token, error) {
-	if p.IsTokenValid() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.getAccessToken(ctx)
+}
+
+// getAccessToken returns the current token or fetches a new one, p.mu must be held
+func (p *OpenIDProvider) getAccessToken(ctx context.Context) (*Token, error) {
+	if p.isTokenValid() {
 		slog.DebugContext(ctx, fmt.Sprintf("Token still active ... %s > %s", p.currentToken.ExpiresAt, time.Now()))
 		return p.currentToken, nil
 	}
@@ -81,8 +91,8 @@ func (p *OpenIDProvider) GetAccessToken(ctx context.Context) (*Token, error) {
 		return nil, err
 	}
 
-	// Set expiration time
-	token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	// Set expiration time (fallback to the exp claim when expires_in is not provided)
+	setTokenExpiry(&token, 0)
 
 	// Store the token
 	p.currentToken = &token
@@ -93,6 +103,12 @@ func (p *OpenIDProvider) GetAccessToken(ctx context.Context) (*Token, error) {
 
 // IsTokenValid checks if the current token is valid and not expired
 func (p *OpenIDProvider) IsTokenValid() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isTokenValid()
+}
+
+func (p *OpenIDProvider) isTokenValid() bool {
 	if p.currentToken == nil {
 		return false
 	}
@@ -101,8 +117,11 @@ func (p *OpenIDProvider) IsTokenValid() bool {
 
 // RefreshToken attempts to refresh the current token
 func (p *OpenIDProvider) RefreshToken(ctx context.Context) (*Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if p.currentToken == nil || p.currentToken.RefreshToken == "" {
-		return p.GetAccessToken(ctx)
+		return p.getAccessToken(ctx)
 	}
 
 	// Update config to use refresh token grant type temporarily
@@ -118,5 +137,5 @@ func (p *OpenIDProvider) RefreshToken(ctx context.Context) (*Token, error) {
 		p.config.RefreshToken = originalRefreshToken
 	}()
 
-	return p.GetAccessToken(ctx)
+	return p.getAccessToken(ctx)
 }
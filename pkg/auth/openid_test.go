@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -179,6 +181,42 @@ func TestOpenIDProvider_GetAccessToken_CachedToken(t *testing.T) {
 	assert.Equal(t, token1.AccessToken, token2.AccessToken)
 }
 
+func TestOpenIDProvider_GetAccessToken_Concurrent(t *testing.T) {
+	var callCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "mock-access-token-12345",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+
+	provider := auth.NewOpenIDProvider(config.AuthSettings{
+		ClientId:  "test-client-id",
+		TokenUrl:  server.URL,
+		GrantType: "client_credentials",
+	})
+
+	// Callers sharing the provider wait for a single token request (run with -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provider.IsTokenValid()
+			token, err := provider.GetAccessToken(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "mock-access-token-12345", token.AccessToken)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), callCount.Load())
+}
+
 func TestOpenIDProvider_GetAccessToken_HttpError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
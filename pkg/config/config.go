@@ -1,7 +1,10 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
@@ -20,16 +23,140 @@ type OsduClient struct {
 
 type AuthSettings struct {
 	ClientId        string   `yaml:"clientId"`
-	ClientSecret    string   `yaml:"clientSecret"`
+	ClientSecret    string   `yaml:"clientSecret" secret:"true"`
 	TenantId        string   `yaml:"tenantId"` // Added for Azure authentication
 	Scopes          []string `yaml:"scopes"`
 	TokenUrl        string   `yaml:"tokenUrl"`
-	RefreshToken    string   `yaml:"refreshToken"`
+	RefreshToken    string   `yaml:"refreshToken" secret:"true"`
 	GrantType       string   `yaml:"grantType"`
 	InternalService bool     `yaml:"internal"`
 	SdkAuth         bool     `yaml:"sdkAuth"` // Added for Azure SDK Authentication (Managed Identity, etc.)
+	// Internal holds the in-cluster settings used when internal is true
+	Internal InternalSettings `yaml:"internalSettings"`
+	// Azure credential selection, takes precedence over sdkAuth when set
+	AzureCredential           string `yaml:"azureCredential"`
+	FederatedTokenFile        string `yaml:"federatedTokenFile"`
+	ManagedIdentityClientId   string `yaml:"managedIdentityClientId"`
+	ManagedIdentityResourceId string `yaml:"managedIdentityResourceId"`
+	CertificatePath           string `yaml:"certificatePath"`
+	CertificatePassword       string `yaml:"certificatePassword" secret:"true"`
+	AuthorityHost             string `yaml:"authorityHost"`
+	DisableInstanceDiscovery  bool   `yaml:"disableInstanceDiscovery"`
+	// Chain lists the providers tried in order when provider is "chain"
+	Chain []ChainedProvider `yaml:"chain"`
 }
 
+// ChainedProvider is a single entry of a chain provider configuration
+type ChainedProvider struct {
+	Provider     string `yaml:"provider"`
+	AuthSettings `yaml:"auth"`
+}
+
+// InternalSettings configures service-to-service calls made from inside the OSDU cluster
+type InternalSettings struct {
+	// UserId and AppId are sent as x-user-id and x-app-id
+	UserId string `yaml:"userId"`
+	AppId  string `yaml:"appId"`
+	// Headers are extra headers sent on every internal request
+	Headers map[string]string `yaml:"headers"`
+	// Namespace and ClusterDomain build <service>.<namespace>.svc.<clusterDomain> URLs
+	Namespace     string `yaml:"namespace"`
+	ClusterDomain string `yaml:"clusterDomain"`
+	// Services holds per service settings keyed by service name (partition, entitlements, ...)
+	Services map[string]InternalServiceSettings `yaml:"services"`
+}
+
+// InternalServiceSettings configures a single service in internal mode
+type InternalServiceSettings struct {
+	// SkipToken sends requests without Authorization header, only when the service allows it
+	SkipToken bool `yaml:"skipToken"`
+	// Url overrides the generated Kubernetes service URL
+	Url string `yaml:"url"`
+}
+
+// OSDU service names used by InternalSettings.Services
+const (
+	ServicePartition    = "partition"
+	ServiceEntitlements = "entitlements"
+	ServiceWorkflow     = "workflow"
+	ServiceSchema       = "schema"
+	ServiceDataset      = "dataset"
+)
+
+// internalServices maps each service to its Kubernetes service name and API path
+var internalServices = map[string]struct{ host, path string }{
+	ServicePartition:    {"partition", "/api/partition/v1"},
+	ServiceEntitlements: {"entitlements", "/api/entitlements/v2"},
+	ServiceWorkflow:     {"workflow", "/api/workflow/v1"},
+	ServiceSchema:       {"schema", "/api/schema-service/v1"},
+	ServiceDataset:      {"dataset", "/api/dataset/v1"},
+}
+
+// SkipToken reports whether requests to service are sent without a token
+func (s InternalSettings) SkipToken(service string) bool {
+	return s.Services[service].SkipToken
+}
+
+// ServiceUrl returns the in-cluster URL of service, or "" when neither a URL nor a namespace is configured
+func (s InternalSettings) ServiceUrl(service string) string {
+	if url := s.Services[service].Url; url != "" {
+		return url
+	}
+
+	known, ok := internalServices[service]
+	if !ok || s.Namespace == "" {
+		return ""
+	}
+
+	domain := s.ClusterDomain
+	if domain == "" {
+		domain = "cluster.local"
+	}
+	return fmt.Sprintf("http://%s.%s.svc.%s%s", known.host, s.Namespace, domain, known.path)
+}
+
+// IdentityHeaders returns the service-to-service identity headers
+func (s InternalSettings) IdentityHeaders() map[string]string {
+	headers := map[string]string{}
+	if s.UserId != "" {
+		headers["x-user-id"] = s.UserId
+	}
+	if s.AppId != "" {
+		headers["x-app-id"] = s.AppId
+	}
+	for k, v := range s.Headers {
+		headers[k] = v
+	}
+	return headers
+}
+
+// ResolveUrls returns settings with the service URLs replaced by their in-cluster URL
+func (s InternalSettings) ResolveUrls(settings OsduSettings) OsduSettings {
+	resolve := func(service, current string) string {
+		if url := s.ServiceUrl(service); url != "" {
+			return url
+		}
+		return current
+	}
+
+	settings.PartitionUrl = resolve(ServicePartition, settings.PartitionUrl)
+	settings.EntitlementsUrl = resolve(ServiceEntitlements, settings.EntitlementsUrl)
+	settings.WorkflowUrl = resolve(ServiceWorkflow, settings.WorkflowUrl)
+	settings.SchemaUrl = resolve(ServiceSchema, settings.SchemaUrl)
+	settings.DatasetUrl = resolve(ServiceDataset, settings.DatasetUrl)
+	return settings
+}
+
+// Supported values for AuthSettings.AzureCredential
+const (
+	AzureCredentialDefault           = "default"
+	AzureCredentialClientSecret      = "clientSecret"
+	AzureCredentialWorkloadIdentity  = "workloadIdentity"
+	AzureCredentialManagedIdentity   = "managedIdentity"
+	AzureCredentialClientCertificate = "clientCertificate"
+	AzureCredentialAzureCLI          = "azureCli"
+)
+
 type OsduSettings struct {
 	DatasetUrl         string `yaml:"datasetUrl"`
 	PartitionUrl       string `yaml:"partitionUrl"`
@@ -39,27 +166,40 @@ type OsduSettings struct {
 	EntitlementsDomain string `yaml:"entitlementsDomain"`
 	PartitionId        string `yaml:"partitionId"`
 	PartitionOverrides string `yaml:"partitionOverrides"`
+	// EntitlementsBootstrap customizes the alias mappings of EntitlementsBootstrap
+	EntitlementsBootstrap EntitlementsBootstrapSettings `yaml:"entitlementsBootstrap"`
 }
 
+// EntitlementsBootstrapSettings are the alias mappings of the tenant provisioning: the defaults,
+// then the file, then the inline mappings
+type EntitlementsBootstrapSettings struct {
+	// Replace drops the default mappings
+	Replace bool `yaml:"replace"`
+	// File is a YAML or JSON file of aliasMappings
+	File          string                     `yaml:"file"`
+	AliasMappings []EntitlementsAliasMapping `yaml:"aliasMappings"`
+}
+
+// EntitlementsAliasMapping maps an alias of the tenant provisioning, i.e SERVICE_PRINCIPAL_AIRFLOW,
+// to a user email or a principal object id
+type EntitlementsAliasMapping struct {
+	AliasId string `yaml:"aliasId"`
+	UserId  string `yaml:"userId"`
+}
+
+// GetAuthSettings returns the auth settings of CONFIG_FILE with the environment overlay applied
 func GetAuthSettings() (AuthSettings, error) {
-	config_file := GetConfigFile()
-	cfg, err := GetConfig(config_file)
+	cfg, err := LoadConfig()
 	if err != nil {
 		slog.Error(err.Error())
 		return AuthSettings{}, err
 	}
-
-	cfg.OsduClient.AuthSettings.ClientId = SetEnvSetting("OSDU_AUTH_CLIENT_ID",
-		cfg.OsduClient.AuthSettings.ClientId)
-	cfg.OsduClient.AuthSettings.ClientSecret = SetEnvSetting("OSDU_AUTH_CLIENT_SECRET",
-		cfg.OsduClient.AuthSettings.ClientSecret)
-
 	return cfg.OsduClient.AuthSettings, nil
 }
 
+// GetOsduSettings returns the client settings of CONFIG_FILE with the environment overlay applied
 func GetOsduSettings() (OsduSettings, error) {
-	config_file := GetConfigFile()
-	cfg, err := GetConfig(config_file)
+	cfg, err := LoadConfig()
 	if err != nil {
 		slog.Error(err.Error())
 		return OsduSettings{}, err
@@ -74,24 +214,31 @@ func SetEnvSetting(envVar string, def string) string {
 	return def
 }
 
+// GetConfig reads config_file. For profiles files the profile is selected by OSDU_PROFILE
+// or the current profile of the file.
 func GetConfig(config_file string) (Config, error) {
-	yamlFile, err := os.ReadFile(config_file)
-	if err != nil {
-		slog.Error(err.Error())
-		return Config{}, err
-	}
+	return GetConfigProfile(config_file, "")
+}
 
+func parseConfig(config_file string, yamlFile []byte) (Config, error) {
 	var c Config
-	err = yaml.Unmarshal(yamlFile, &c)
-	if err != nil {
+	decoder := yaml.NewDecoder(bytes.NewReader(yamlFile))
+	err := decoder.Decode(&c)
+	if err != nil && !errors.Is(err, io.EOF) {
 		slog.Error(err.Error())
 		return Config{}, err
 	}
+
+	// Only the first document is used, profiles replace multi document files
+	var next interface{}
+	if decoder.Decode(&next) == nil {
+		slog.Warn(fmt.Sprintf("%s holds several YAML documents, only the first one is used. Use profiles to keep several configurations", config_file))
+	}
 	return c, nil
 }
 
 func GetConfigFile() string {
-	config_file_location := SetEnvSetting("CONFIG_FILE", "./config/default.yaml")
-	slog.Info(fmt.Sprintf("Getting config from %s", config_file_location))
+	config_file_location := SetEnvSetting("CONFIG_FILE", DefaultConfigFile)
+	slog.Debug(fmt.Sprintf("Getting config from %s", config_file_location))
 	return config_file_location
 }
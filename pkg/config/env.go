@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is the prefix of every configuration environment variable
+const EnvPrefix = "OSDU"
+
+// Kinds of values supported by the environment overlay
+const (
+	EnvKindString = "string"
+	EnvKindBool   = "bool"
+	EnvKindList   = "list" // comma separated
+	EnvKindMap    = "map"  // comma separated key=value pairs
+)
+
+// EnvVar describes an environment variable overriding a configuration field
+type EnvVar struct {
+	// Name of the environment variable, i.e OSDU_CLIENT_PARTITION_ID
+	Name string
+	// Path of the field in the YAML file, i.e osdu.client.partitionId
+	Path string
+	// Kind of value, one of EnvKindString, EnvKindBool, EnvKindList, EnvKindMap
+	Kind string
+}
+
+// configField is a leaf field of Config reachable from YAML and environment
+type configField struct {
+	EnvVar
+	index []int
+}
+
+var configFields = collectFields(reflect.TypeOf(Config{}), nil, nil)
+
+// collectFields walks the yaml tags of t and returns every leaf that can be set from a string
+func collectFields(t reflect.Type, index []int, path []string) []configField {
+	var fields []configField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		fieldPath := append(append([]string{}, path...), name)
+
+		var kind string
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			fields = append(fields, collectFields(f.Type, fieldIndex, fieldPath)...)
+			continue
+		case f.Type.Kind() == reflect.String:
+			kind = EnvKindString
+		case f.Type.Kind() == reflect.Bool:
+			kind = EnvKindBool
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.String:
+			kind = EnvKindList
+		case f.Type.Kind() == reflect.Map && f.Type.Key().Kind() == reflect.String && f.Type.Elem().Kind() == reflect.String:
+			kind = EnvKindMap
+		default:
+			// Lists and maps of structs (chain, internal services) are only configurable from files
+			continue
+		}
+
+		fields = append(fields, configField{
+			EnvVar: EnvVar{
+				Name: envName(fieldPath),
+				Path: strings.Join(fieldPath, "."),
+				Kind: kind,
+			},
+			index: fieldIndex,
+		})
+	}
+
+	return fields
+}
+
+// envName converts a yaml path (osdu.client.partitionId) into OSDU_CLIENT_PARTITION_ID.
+// The leading osdu section is the prefix itself.
+func envName(path []string) string {
+	parts := []string{EnvPrefix}
+	for i, p := range path {
+		if i == 0 && strings.EqualFold(p, EnvPrefix) {
+			continue
+		}
+		parts = append(parts, toScreamingSnake(p))
+	}
+	return strings.Join(parts, "_")
+}
+
+func toScreamingSnake(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// EnvVars lists every environment variable supported by ApplyEnv, sorted by name
+func EnvVars() []EnvVar {
+	vars := make([]EnvVar, 0, len(configFields))
+	for _, f := range configFields {
+		vars = append(vars, f.EnvVar)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// ApplyEnv overrides cfg with every OSDU_* environment variable that is set.
+// It returns the names of the applied variables.
+func ApplyEnv(cfg *Config) ([]string, error) {
+	root := reflect.ValueOf(cfg).Elem()
+	var applied []string
+	var errs []error
+
+	for _, f := range configFields {
+		raw, ok := os.LookupEnv(f.Name)
+		if !ok {
+			continue
+		}
+		if err := setField(root.FieldByIndex(f.index), f.Kind, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name, err))
+			continue
+		}
+		applied = append(applied, f.Name)
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// LoadFromEnv builds a configuration from environment variables only, without any file
+func LoadFromEnv() (Config, error) {
+	var c Config
+	if _, err := ApplyEnv(&c); err != nil {
+		return Config{}, err
+	}
+	if err := ResolveSecrets(&c); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// defaultLoader backs LoadConfig, its cache is shared by the getters of this package
+var defaultLoader = NewLoader()
+
+// LoadConfig merges the built-in defaults, CONFIG_FILE, the environment overlay and resolves
+// secret references. When CONFIG_FILE is not set and the default file does not exist, the
+// configuration is loaded from the environment only. The result is cached until an input changes.
+func LoadConfig() (Config, error) {
+	return defaultLoader.Load()
+}
+
+// LoadConfigProfile is LoadConfig with an explicit profile of a profiles file.
+// An empty profile selects OSDU_PROFILE, then the current profile of the file.
+func LoadConfigProfile(profile string) (Config, error) {
+	if profile == "" {
+		return LoadConfig()
+	}
+	return NewLoader().WithProfile(profile).Load()
+}
+
+func setField(v reflect.Value, kind string, raw string) error {
+	switch kind {
+	case EnvKindString:
+		v.SetString(raw)
+	case EnvKindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case EnvKindList:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	case EnvKindMap:
+		m := map[string]string{}
+		for _, pair := range splitList(raw) {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid key=value pair %q", pair)
+			}
+			m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported kind %s", kind)
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	values := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
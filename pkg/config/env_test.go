@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvVars(t *testing.T) {
+	names := map[string]config.EnvVar{}
+	for _, v := range config.EnvVars() {
+		names[v.Name] = v
+	}
+
+	assert.Equal(t, "osdu.provider", names["OSDU_PROVIDER"].Path)
+	assert.Equal(t, "osdu.auth.clientId", names["OSDU_AUTH_CLIENT_ID"].Path)
+	assert.Equal(t, "osdu.auth.clientSecret", names["OSDU_AUTH_CLIENT_SECRET"].Path)
+	assert.Equal(t, "osdu.auth.tenantId", names["OSDU_AUTH_TENANT_ID"].Path)
+	assert.Equal(t, config.EnvKindList, names["OSDU_AUTH_SCOPES"].Kind)
+	assert.Equal(t, config.EnvKindBool, names["OSDU_AUTH_INTERNAL"].Kind)
+	assert.Equal(t, config.EnvKindMap, names["OSDU_AUTH_INTERNAL_SETTINGS_HEADERS"].Kind)
+	assert.Equal(t, "osdu.client.partitionId", names["OSDU_CLIENT_PARTITION_ID"].Path)
+	assert.Equal(t, "osdu.client.entitlementsUrl", names["OSDU_CLIENT_ENTITLEMENTS_URL"].Path)
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("OSDU_PROVIDER", "azure")
+	t.Setenv("OSDU_AUTH_SCOPES", "openid, email")
+	t.Setenv("OSDU_AUTH_SDK_AUTH", "true")
+	t.Setenv("OSDU_AUTH_INTERNAL_SETTINGS_HEADERS", "x-user-id=datafier@service.local,x-app-id=sdk")
+	t.Setenv("OSDU_CLIENT_PARTITION_ID", "osdu")
+
+	cfg := config.Config{}
+	cfg.OsduClient.PartitionId = "opendes"
+	cfg.OsduClient.PartitionUrl = "https://osdu/api/partition/v1"
+
+	applied, err := config.ApplyEnv(&cfg)
+	require.NoError(t, err)
+
+	assert.Len(t, applied, 5)
+	assert.Equal(t, "azure", cfg.OsduClient.Provider)
+	assert.Equal(t, []string{"openid", "email"}, cfg.OsduClient.Scopes)
+	assert.True(t, cfg.OsduClient.SdkAuth)
+	assert.Equal(t, "sdk", cfg.OsduClient.Internal.Headers["x-app-id"])
+	assert.Equal(t, "osdu", cfg.OsduClient.PartitionId)
+	// Fields without a variable are kept
+	assert.Equal(t, "https://osdu/api/partition/v1", cfg.OsduClient.PartitionUrl)
+}
+
+func TestApplyEnv_InvalidValue(t *testing.T) {
+	t.Setenv("OSDU_AUTH_INTERNAL", "maybe")
+
+	cfg := config.Config{}
+	_, err := config.ApplyEnv(&cfg)
+	assert.ErrorContains(t, err, "OSDU_AUTH_INTERNAL")
+}
+
+func TestLoadConfig_FileAndEnv(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+osdu:
+  provider: openid
+  auth:
+    clientId: datafier
+  client:
+    partitionId: opendes
+    entitlementsUrl: https://osdu/api/entitlements/v2
+`), 0600))
+
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("OSDU_CLIENT_ENTITLEMENTS_URL", "http://entitlements:8080/api/entitlements/v2")
+
+	settings, err := config.GetOsduSettings()
+	require.NoError(t, err)
+	assert.Equal(t, "opendes", settings.PartitionId)
+	assert.Equal(t, "http://entitlements:8080/api/entitlements/v2", settings.EntitlementsUrl)
+
+	auth, err := config.GetAuthSettings()
+	require.NoError(t, err)
+	assert.Equal(t, "datafier", auth.ClientId)
+}
+
+func TestLoadConfig_EnvOnly(t *testing.T) {
+	// Run from a directory without ./config/default.yaml
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Restored by t.Setenv at the end of the test
+	t.Setenv("CONFIG_FILE", "")
+	os.Unsetenv("CONFIG_FILE")
+
+	t.Setenv("OSDU_PROVIDER", "openid")
+	t.Setenv("OSDU_AUTH_TOKEN_URL", "https://keycloak/realms/osdu/protocol/openid-connect/token")
+	t.Setenv("OSDU_CLIENT_PARTITION_ID", "opendes")
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "openid", cfg.OsduClient.Provider)
+	assert.Equal(t, "https://keycloak/realms/osdu/protocol/openid-connect/token", cfg.OsduClient.TokenUrl)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+}
+
+func TestLoadConfig_ExplicitFileMissing(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	_, err := config.LoadConfig()
+	assert.Error(t, err)
+}
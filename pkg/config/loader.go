@@ -0,0 +1,554 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is read when CONFIG_FILE is not set
+const DefaultConfigFile = "./config/default.yaml"
+
+// Kinds of configuration value sources, from lowest to highest precedence
+const (
+	SourceDefault  = "default"
+	SourceFile     = "file"
+	SourceEnv      = "env"
+	SourceOverride = "override"
+)
+
+// Source tells where an effective configuration value came from
+type Source struct {
+	// Kind is one of SourceDefault, SourceFile, SourceEnv, SourceOverride
+	Kind string
+	// File and Line locate file values, Profile is the profile defining the value
+	File    string
+	Line    int
+	Profile string
+	// Env is the variable of env values
+	Env string
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		location := fmt.Sprintf("file:%s:%d", s.File, s.Line)
+		if s.Profile != "" {
+			location += fmt.Sprintf(" (profile %s)", s.Profile)
+		}
+		return location
+	case SourceEnv:
+		return "env:" + s.Env
+	default:
+		return s.Kind
+	}
+}
+
+// ValueSource is an effective configuration value and its source
+type ValueSource struct {
+	// Path is the YAML path, i.e osdu.client.partitionId
+	Path string
+	// Value is the effective value, secrets are masked
+	Value  string
+	Source Source
+}
+
+// DefaultConfig returns the built-in defaults, the lowest layer of a Loader
+func DefaultConfig() Config {
+	var c Config
+	c.OsduClient.Provider = ProviderOpenID
+	c.OsduClient.GrantType = "client_credentials"
+	c.OsduClient.EntitlementsDomain = "group"
+	return c
+}
+
+// Loader merges, once, the built-in defaults, the configuration file (and its profile),
+// the environment overlay and explicit overrides. The result is cached until the file,
+// the OSDU_* variables or a referenced secret change.
+type Loader struct {
+	mu         sync.Mutex
+	configFile string
+	profile    string
+	defaults   Config
+	overrides  map[string]string
+
+	cached      *Config
+	fingerprint string
+	sources     map[string]Source
+	secretRefs  []string
+	// rejected is the fingerprint of inputs whose configuration was rejected, see Reject
+	rejected string
+}
+
+// NewLoader creates a loader reading CONFIG_FILE (./config/default.yaml when unset)
+func NewLoader() *Loader {
+	return &Loader{
+		defaults:  DefaultConfig(),
+		overrides: map[string]string{},
+	}
+}
+
+// WithConfigFile reads configFile instead of CONFIG_FILE
+func (l *Loader) WithConfigFile(configFile string) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.configFile = configFile
+	l.cached = nil
+	return l
+}
+
+// WithProfile selects a profile of a profiles file, OSDU_PROFILE or the current profile otherwise
+func (l *Loader) WithProfile(profile string) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.profile = profile
+	l.cached = nil
+	return l
+}
+
+// WithDefaults replaces the built-in defaults
+func (l *Loader) WithDefaults(defaults Config) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaults = defaults
+	l.cached = nil
+	return l
+}
+
+// Set overrides a value by YAML path (see EnvVars for the paths), with the same syntax as
+// the environment variables. Overrides win over every other layer.
+func (l *Loader) Set(path string, value string) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides[path] = value
+	l.cached = nil
+	return l
+}
+
+// Load returns the merged configuration, from cache when nothing changed
+func (l *Loader) Load() (Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cached != nil {
+		if fingerprint := l._fingerprint(l.secretRefs); fingerprint == l.fingerprint || fingerprint == l.rejected {
+			return *l.cached, nil
+		}
+	}
+	return l._load()
+}
+
+// Reload merges the configuration again, ignoring the cache
+func (l *Loader) Reload() (Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l._load()
+}
+
+// Candidate is a configuration merged by Resolve that the loader does not serve yet
+type Candidate struct {
+	Config      Config
+	fingerprint string
+	sources     map[string]Source
+	secretRefs  []string
+}
+
+// Fingerprint is the fingerprint of the inputs the candidate was merged from
+func (c *Candidate) Fingerprint() string {
+	return c.fingerprint
+}
+
+// Resolve merges the configuration again, like Reload, but leaves the cached configuration,
+// its sources and its secrets untouched until the candidate is passed to Commit
+func (l *Loader) Resolve() (*Candidate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l._resolve()
+}
+
+// Commit makes candidate the configuration served by Load, Source and Provenance
+func (l *Loader) Commit(candidate *Candidate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l._commit(candidate)
+}
+
+// Reject keeps Load serving the committed configuration while the inputs match fingerprint, a
+// value of Fingerprint taken before resolving the configuration that was rejected
+func (l *Loader) Reject(fingerprint string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rejected = fingerprint
+}
+
+// Fingerprint changes whenever an input of the configuration changes: the file, the
+// selected profile, an OSDU_* variable or a secret referenced by the last load. It is a
+// sha256 hash, the values of the variables and secrets are never part of it.
+func (l *Loader) Fingerprint() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l._fingerprint(l.secretRefs)
+}
+
+// ConfigFile returns the file read by the loader, and whether it was explicitly set
+func (l *Loader) ConfigFile() (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l._configFile()
+}
+
+func (l *Loader) _configFile() (string, bool) {
+	if l.configFile != "" {
+		return l.configFile, true
+	}
+	if file, ok := os.LookupEnv("CONFIG_FILE"); ok {
+		return file, true
+	}
+	return DefaultConfigFile, false
+}
+
+// Source returns where the effective value of path came from
+func (l *Loader) Source(path string) Source {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if source, ok := l.sources[path]; ok {
+		return source
+	}
+	return Source{Kind: SourceDefault}
+}
+
+// Provenance lists every effective value of the last load with its source, sorted by path
+func (l *Loader) Provenance() []ValueSource {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cached == nil {
+		return nil
+	}
+
+	redacted := l.cached.Redacted()
+	root := reflect.ValueOf(redacted)
+
+	values := []ValueSource{}
+	seen := map[string]bool{}
+	for _, f := range configFields {
+		seen[f.Path] = true
+		source, ok := l.sources[f.Path]
+		if !ok {
+			source = Source{Kind: SourceDefault}
+		}
+		values = append(values, ValueSource{
+			Path:   f.Path,
+			Value:  formatField(root.FieldByIndex(f.index), f.Kind),
+			Source: source,
+		})
+	}
+	// Values only configurable from files, such as the chain entries
+	for path, source := range l.sources {
+		if !seen[path] && !l._isParentOfField(path) {
+			values = append(values, ValueSource{Path: path, Value: "(see file)", Source: source})
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Path < values[j].Path })
+	return values
+}
+
+// Explain prints the effective configuration, one value per line with its source
+func (l *Loader) Explain() string {
+	var b strings.Builder
+	for _, v := range l.Provenance() {
+		fmt.Fprintf(&b, "%s = %s\t# %s\n", v.Path, v.Value, v.Source)
+	}
+	return b.String()
+}
+
+func (l *Loader) _isParentOfField(path string) bool {
+	for _, f := range configFields {
+		if strings.HasPrefix(f.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Loader) _load() (Config, error) {
+	candidate, err := l._resolve()
+	if err != nil {
+		return Config{}, err
+	}
+	l._commit(candidate)
+	return candidate.Config, nil
+}
+
+func (l *Loader) _commit(candidate *Candidate) {
+	cfg := candidate.Config
+	l.cached = &cfg
+	l.sources = candidate.sources
+	l.secretRefs = candidate.secretRefs
+	l.fingerprint = candidate.fingerprint
+	l.rejected = ""
+}
+
+func (l *Loader) _resolve() (*Candidate, error) {
+	// The layers are decoded over a copy, decoding a map would otherwise add to the defaults
+	cfg := cloneConfig(l.defaults)
+	sources := map[string]Source{}
+
+	configFile, explicit := l._configFile()
+	if _, err := os.Stat(configFile); errors.Is(err, os.ErrNotExist) && !explicit {
+		if l.profile != "" {
+			return nil, fmt.Errorf("profile %q requested but %s does not exist", l.profile, configFile)
+		}
+	} else {
+		fileSources, err := decodeFileLayer(configFile, l.profile, &cfg)
+		if err != nil {
+			return nil, err
+		}
+		for path, source := range fileSources {
+			sources[path] = source
+		}
+	}
+
+	applied, err := ApplyEnv(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range applied {
+		for _, f := range configFields {
+			if f.Name == name {
+				sources[f.Path] = Source{Kind: SourceEnv, Env: name}
+			}
+		}
+	}
+
+	if err := applyOverrides(&cfg, l.overrides); err != nil {
+		return nil, err
+	}
+	for path := range l.overrides {
+		sources[path] = Source{Kind: SourceOverride}
+	}
+
+	secretRefs, err := resolveSecrets(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Candidate{
+		Config:      cfg,
+		fingerprint: l._fingerprint(secretRefs),
+		sources:     sources,
+		secretRefs:  secretRefs,
+	}, nil
+}
+
+// _fingerprint changes when any input of a load referencing secretRefs changes, the inputs are
+// only written to the hash so the values of the secrets never leave the loader
+func (l *Loader) _fingerprint(secretRefs []string) string {
+	b := sha256.New()
+
+	configFile, _ := l._configFile()
+	fmt.Fprintf(b, "%s|%s|%s\n", configFile, os.Getenv(ProfileEnv), statFingerprint(configFile))
+
+	for _, f := range configFields {
+		if value, ok := os.LookupEnv(f.Name); ok {
+			fmt.Fprintf(b, "%s=%s\n", f.Name, value)
+		}
+	}
+
+	for _, ref := range secretRefs {
+		switch {
+		case strings.HasPrefix(ref, SecretRefEnv):
+			value, ok := os.LookupEnv(strings.TrimPrefix(ref, SecretRefEnv))
+			fmt.Fprintf(b, "%s=%t:%s\n", ref, ok, value)
+		case strings.HasPrefix(ref, SecretRefFile):
+			fmt.Fprintf(b, "%s=%s\n", ref, statFingerprint(strings.TrimPrefix(ref, SecretRefFile)))
+		}
+	}
+	return hex.EncodeToString(b.Sum(nil))
+}
+
+// cloneConfig returns a copy of cfg that shares no map or slice with it
+func cloneConfig(cfg Config) Config {
+	cfg.OsduClient.AuthSettings = cloneAuthSettings(cfg.OsduClient.AuthSettings)
+	cfg.OsduClient.EntitlementsBootstrap.AliasMappings = slices.Clone(cfg.OsduClient.EntitlementsBootstrap.AliasMappings)
+	return cfg
+}
+
+func cloneAuthSettings(auth AuthSettings) AuthSettings {
+	auth.Scopes = slices.Clone(auth.Scopes)
+	auth.Internal.Headers = maps.Clone(auth.Internal.Headers)
+	auth.Internal.Services = maps.Clone(auth.Internal.Services)
+	if auth.Chain != nil {
+		chain := make([]ChainedProvider, len(auth.Chain))
+		for i, provider := range auth.Chain {
+			provider.AuthSettings = cloneAuthSettings(provider.AuthSettings)
+			chain[i] = provider
+		}
+		auth.Chain = chain
+	}
+	return auth
+}
+
+func statFingerprint(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "missing"
+	}
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// decodeFileLayer decodes configFile (or its profile) over cfg and returns the line of each value
+func decodeFileLayer(configFile string, profile string, cfg *Config) (map[string]Source, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+
+	sources := map[string]Source{}
+	if !IsProfilesFile(data) {
+		if profile != "" {
+			return nil, fmt.Errorf("profile %q requested but %s has no profiles", profile, configFile)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
+		if len(doc.Content) > 0 {
+			collectLines(doc.Content[0], nil, Source{Kind: SourceFile, File: configFile}, sources)
+		}
+		return sources, nil
+	}
+
+	profiles, err := ParseProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	name, err := profiles.Select(profile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	merged, err := profiles.resolve(name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+
+	mergedYaml, err := yaml.Marshal(map[string]interface{}{"osdu": merged})
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(mergedYaml, cfg); err != nil {
+		return nil, fmt.Errorf("%s: profile %q: %w", configFile, name, err)
+	}
+
+	// Lines of the base profiles first, the derived profiles override them
+	var lineage []string
+	for p := name; p != ""; p = profiles.Profiles[p].Inherits {
+		lineage = append([]string{p}, lineage...)
+	}
+	profileNodes := mappingValue(doc.Content[0], "profiles")
+	for _, p := range lineage {
+		osduNode := mappingValue(mappingValue(profileNodes, p), "osdu")
+		if osduNode != nil {
+			collectLines(osduNode, []string{"osdu"}, Source{Kind: SourceFile, File: configFile, Profile: p}, sources)
+		}
+	}
+	return sources, nil
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// collectLines records the line of every key of a mapping node, by YAML path
+func collectLines(node *yaml.Node, path []string, source Source, sources map[string]Source) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := append(append([]string{}, path...), key.Value)
+
+		s := source
+		s.Line = key.Line
+		sources[strings.Join(keyPath, ".")] = s
+
+		collectLines(value, keyPath, source, sources)
+	}
+}
+
+// applyOverrides sets every override by YAML path
+func applyOverrides(cfg *Config, overrides map[string]string) error {
+	root := reflect.ValueOf(cfg).Elem()
+	var errs []error
+
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		field, ok := fieldByPath(path)
+		if !ok {
+			errs = append(errs, fmt.Errorf("override %s: unknown configuration path", path))
+			continue
+		}
+		if err := setField(root.FieldByIndex(field.index), field.Kind, overrides[path]); err != nil {
+			errs = append(errs, fmt.Errorf("override %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func fieldByPath(path string) (configField, bool) {
+	for _, f := range configFields {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return configField{}, false
+}
+
+// formatField prints a value with the syntax of the environment variables
+func formatField(v reflect.Value, kind string) string {
+	switch kind {
+	case EnvKindBool:
+		return strconv.FormatBool(v.Bool())
+	case EnvKindList:
+		return strings.Join(v.Interface().([]string), ",")
+	case EnvKindMap:
+		m := v.Interface().(map[string]string)
+		pairs := make([]string, 0, len(m))
+		for k, value := range m {
+			pairs = append(pairs, k+"="+value)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return v.String()
+	}
+}
@@ -0,0 +1,182 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_LayersAndProvenance(t *testing.T) {
+	configFile := writeConfig(t, `osdu:
+  auth:
+    clientId: datafier
+    clientSecret: plain-secret
+  client:
+    partitionId: opendes
+    entitlementsUrl: https://osdu/api/entitlements/v2
+`)
+	t.Setenv(config.ProfileEnv, "")
+	t.Setenv("OSDU_CLIENT_ENTITLEMENTS_URL", "http://entitlements:8080/api/entitlements/v2")
+
+	loader := config.NewLoader().
+		WithConfigFile(configFile).
+		Set("osdu.client.partitionId", "tenant-a")
+
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	// default, file, env and override layers
+	assert.Equal(t, "openid", cfg.OsduClient.Provider)
+	assert.Equal(t, "datafier", cfg.OsduClient.ClientId)
+	assert.Equal(t, "http://entitlements:8080/api/entitlements/v2", cfg.OsduClient.EntitlementsUrl)
+	assert.Equal(t, "tenant-a", cfg.OsduClient.PartitionId)
+
+	assert.Equal(t, config.Source{Kind: config.SourceDefault}, loader.Source("osdu.provider"))
+	assert.Equal(t, "file:"+configFile+":3", loader.Source("osdu.auth.clientId").String())
+	assert.Equal(t, "env:OSDU_CLIENT_ENTITLEMENTS_URL", loader.Source("osdu.client.entitlementsUrl").String())
+	assert.Equal(t, "override", loader.Source("osdu.client.partitionId").String())
+
+	explain := loader.Explain()
+	assert.Contains(t, explain, "osdu.auth.clientId = datafier\t# file:"+configFile+":3")
+	assert.Contains(t, explain, "osdu.auth.clientSecret = ******")
+	assert.NotContains(t, explain, "plain-secret")
+}
+
+func TestLoader_ProfileLines(t *testing.T) {
+	configFile := writeConfig(t, profilesYaml)
+	t.Setenv(config.ProfileEnv, "")
+
+	loader := config.NewLoader().WithConfigFile(configFile).WithProfile("prod")
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	clientId := loader.Source("osdu.auth.clientId")
+	assert.Equal(t, "base", clientId.Profile)
+	assert.Equal(t, 8, clientId.Line)
+
+	partitionId := loader.Source("osdu.client.partitionId")
+	assert.Equal(t, "prod", partitionId.Profile)
+	assert.Equal(t, 25, partitionId.Line)
+}
+
+func TestLoader_Cache(t *testing.T) {
+	configFile := writeConfig(t, "osdu:\n  client:\n    partitionId: opendes\n")
+	t.Setenv(config.ProfileEnv, "")
+	t.Setenv("OSDU_CLIENT_PARTITION_ID", "")
+	os.Unsetenv("OSDU_CLIENT_PARTITION_ID")
+
+	loader := config.NewLoader().WithConfigFile(configFile)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+
+	// The file is not read again while its size and modification time are unchanged
+	info, err := os.Stat(configFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(configFile, []byte("osdu:\n  client:\n    partitionId: tenantx\n"), 0600))
+	require.NoError(t, os.Chtimes(configFile, info.ModTime(), info.ModTime()))
+	cfg, err = loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+
+	// A changed variable invalidates the cache
+	t.Setenv("OSDU_CLIENT_PARTITION_ID", "from-env")
+	cfg, err = loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.OsduClient.PartitionId)
+	os.Unsetenv("OSDU_CLIENT_PARTITION_ID")
+
+	// So does a changed file
+	require.NoError(t, os.WriteFile(configFile, []byte("osdu:\n  client:\n    partitionId: changed-partition\n"), 0600))
+	require.NoError(t, os.Chtimes(configFile, time.Now(), time.Now().Add(time.Second)))
+	cfg, err = loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "changed-partition", cfg.OsduClient.PartitionId)
+}
+
+func TestLoader_UnknownOverride(t *testing.T) {
+	_, err := config.NewLoader().
+		WithConfigFile(writeConfig(t, "osdu: {}\n")).
+		Set("osdu.client.partition", "x").
+		Load()
+	assert.ErrorContains(t, err, "override osdu.client.partition: unknown configuration path")
+}
+
+func TestLoader_FingerprintHidesSecrets(t *testing.T) {
+	configFile := writeConfig(t, "osdu:\n  auth:\n    clientSecret: env:TEST_FINGERPRINT_SECRET\n")
+	t.Setenv("TEST_FINGERPRINT_SECRET", "resolved-secret-value")
+	t.Setenv("OSDU_AUTH_CLIENT_ID", "clear-client-id")
+
+	loader := config.NewLoader().WithConfigFile(configFile)
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	fingerprint := loader.Fingerprint()
+	assert.Len(t, fingerprint, 64)
+	assert.NotContains(t, fingerprint, "resolved-secret-value")
+	assert.NotContains(t, fingerprint, "clear-client-id")
+
+	// The hash still follows the referenced secret
+	t.Setenv("TEST_FINGERPRINT_SECRET", "rotated-secret-value")
+	assert.NotEqual(t, fingerprint, loader.Fingerprint())
+
+	file, explicit := loader.ConfigFile()
+	assert.Equal(t, configFile, file)
+	assert.True(t, explicit)
+}
+
+func TestLoader_ReloadRemovesMapKeys(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	configFile := writeConfig(t, "osdu:\n  auth:\n    internalSettings:\n      headers:\n        x-a: a\n")
+
+	defaults := config.DefaultConfig()
+	defaults.OsduClient.Internal.Headers = map[string]string{"x-def": "def"}
+	loader := config.NewLoader().WithConfigFile(configFile).WithDefaults(defaults)
+
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-a": "a", "x-def": "def"}, cfg.OsduClient.Internal.Headers)
+	assert.Equal(t, map[string]string{"x-def": "def"}, defaults.OsduClient.Internal.Headers, "the defaults are not modified")
+
+	// A header removed from the file is gone after a reload
+	require.NoError(t, os.WriteFile(configFile, []byte("osdu:\n  auth:\n    internalSettings:\n      headers:\n        x-b: b\n"), 0600))
+	cfg, err = loader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-b": "b", "x-def": "def"}, cfg.OsduClient.Internal.Headers)
+}
+
+func TestLoader_ResolveCommitReject(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	os.Unsetenv("OSDU_CLIENT_PARTITION_ID")
+	configFile := writeConfig(t, "osdu:\n  client:\n    partitionId: opendes\n")
+	loader := config.NewLoader().WithConfigFile(configFile)
+	_, err := loader.Load()
+	require.NoError(t, err)
+	accepted := loader.Fingerprint()
+
+	// A resolved candidate is not served until it is committed
+	require.NoError(t, os.WriteFile(configFile, []byte("osdu:\n  client:\n    partitionId: tenant-a\n"), 0600))
+	require.NoError(t, os.Chtimes(configFile, time.Now(), time.Now().Add(time.Second)))
+	changed := loader.Fingerprint()
+	candidate, err := loader.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", candidate.Config.OsduClient.PartitionId)
+	assert.Equal(t, "file:"+configFile+":3", loader.Source("osdu.client.partitionId").String())
+	assert.NotEqual(t, accepted, candidate.Fingerprint())
+
+	// A rejected change keeps the committed configuration
+	loader.Reject(changed)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+
+	loader.Commit(candidate)
+	cfg, err = loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", cfg.OsduClient.PartitionId)
+	assert.Equal(t, candidate.Fingerprint(), loader.Fingerprint())
+}
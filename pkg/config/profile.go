@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ProfileEnv selects the profile of a profiles file, overriding its current profile
+const ProfileEnv = "OSDU_PROFILE"
+
+// ProfilesFile is a kubeconfig-style file holding several named configurations
+//
+//	current: dev
+//	profiles:
+//	  base:
+//	    osdu: ...
+//	  dev:
+//	    inherits: base
+//	    osdu: ...
+type ProfilesFile struct {
+	// Current is the profile used when none is selected
+	Current string `yaml:"current"`
+	// Profiles are keyed by name
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a named configuration, optionally inheriting from another profile
+type Profile struct {
+	// Inherits is the name of the base profile, its settings are deep merged under this profile
+	Inherits string `yaml:"inherits"`
+	// Osdu holds the raw osdu section, decoded once inheritance is resolved
+	Osdu map[string]interface{} `yaml:"osdu"`
+}
+
+// IsProfilesFile reports whether data is a profiles file rather than a single configuration
+func IsProfilesFile(data []byte) bool {
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return false
+	}
+	_, ok := root["profiles"]
+	return ok
+}
+
+// ParseProfiles decodes a profiles file
+func ParseProfiles(data []byte) (ProfilesFile, error) {
+	var p ProfilesFile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return ProfilesFile{}, err
+	}
+	if len(p.Profiles) == 0 {
+		return ProfilesFile{}, fmt.Errorf("profiles file has no profile")
+	}
+	return p, nil
+}
+
+// Names returns the profile names, sorted
+func (p ProfilesFile) Names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select returns the profile to use: name when set, then OSDU_PROFILE, then current.
+// A file with a single profile does not need a current profile.
+func (p ProfilesFile) Select(name string) (string, error) {
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	if name == "" {
+		name = p.Current
+	}
+	if name == "" && len(p.Profiles) == 1 {
+		name = p.Names()[0]
+	}
+	if name == "" {
+		return "", fmt.Errorf("no profile selected, set current or %s (available: %s)",
+			ProfileEnv, strings.Join(p.Names(), ", "))
+	}
+	if _, ok := p.Profiles[name]; !ok {
+		return "", fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(p.Names(), ", "))
+	}
+	return name, nil
+}
+
+// Config resolves the inheritance of profile name and returns its configuration
+func (p ProfilesFile) Config(name string) (Config, error) {
+	merged, err := p.resolve(name, nil)
+	if err != nil {
+		return Config{}, err
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{"osdu": merged})
+	if err != nil {
+		return Config{}, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("profile %q: %w", name, err)
+	}
+	return c, nil
+}
+
+// resolve merges profile name over its base profiles, detecting inheritance cycles
+func (p ProfilesFile) resolve(name string, seen []string) (map[string]interface{}, error) {
+	for _, s := range seen {
+		if s == name {
+			return nil, fmt.Errorf("profile inheritance cycle: %s -> %s", strings.Join(seen, " -> "), name)
+		}
+	}
+
+	profile, ok := p.Profiles[name]
+	if !ok {
+		if len(seen) > 0 {
+			return nil, fmt.Errorf("profile %q inherits from unknown profile %q", seen[len(seen)-1], name)
+		}
+		return nil, fmt.Errorf("profile %q not found", name)
+	}
+
+	if profile.Inherits == "" {
+		return mergeMaps(nil, profile.Osdu), nil
+	}
+
+	base, err := p.resolve(profile.Inherits, append(seen, name))
+	if err != nil {
+		return nil, err
+	}
+	return mergeMaps(base, profile.Osdu), nil
+}
+
+// mergeMaps returns a deep copy of base with override merged on top.
+// Nested maps are merged, any other value (including lists) replaces the base value.
+func mergeMaps(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		if child, ok := v.(map[string]interface{}); ok {
+			if parent, ok := merged[k].(map[string]interface{}); ok {
+				merged[k] = mergeMaps(parent, child)
+				continue
+			}
+			merged[k] = mergeMaps(nil, child)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// GetConfigProfile reads config_file and returns the configuration of profile with its
+// secret references resolved. Files without profiles are read as a single configuration
+// and profile must be empty.
+func GetConfigProfile(config_file string, profile string) (Config, error) {
+	cfg, err := readConfigProfile(config_file, profile)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := ResolveSecrets(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// readConfigProfile is GetConfigProfile without secret resolution
+func readConfigProfile(config_file string, profile string) (Config, error) {
+	data, err := os.ReadFile(config_file)
+	if err != nil {
+		slog.Error(err.Error())
+		return Config{}, err
+	}
+
+	if !IsProfilesFile(data) {
+		if profile != "" {
+			return Config{}, fmt.Errorf("profile %q requested but %s has no profiles", profile, config_file)
+		}
+		return parseConfig(config_file, data)
+	}
+
+	profiles, err := ParseProfiles(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", config_file, err)
+	}
+	name, err := profiles.Select(profile)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", config_file, err)
+	}
+	slog.Debug(fmt.Sprintf("Using profile %s of %s", name, config_file))
+	return profiles.Config(name)
+}
+
+// ListProfiles returns the profile names of config_file and its current profile
+func ListProfiles(config_file string) ([]string, string, error) {
+	data, err := os.ReadFile(config_file)
+	if err != nil {
+		return nil, "", err
+	}
+	if !IsProfilesFile(data) {
+		return nil, "", nil
+	}
+	profiles, err := ParseProfiles(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return profiles.Names(), profiles.Current, nil
+}
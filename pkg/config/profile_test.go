@@ -0,0 +1,156 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesYaml = `
+current: dev
+profiles:
+  base:
+    osdu:
+      provider: openid
+      auth:
+        clientId: datafier
+        scopes: [openid]
+        tokenUrl: https://keycloak/realms/osdu/protocol/openid-connect/token
+      client:
+        partitionId: opendes
+        entitlementsDomain: group
+  dev:
+    inherits: base
+    osdu:
+      client:
+        partitionUrl: https://osdu-dev/api/partition/v1
+  prod:
+    inherits: base
+    osdu:
+      auth:
+        scopes: [openid, email]
+      client:
+        partitionId: prod
+        partitionUrl: https://osdu-prod/api/partition/v1
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(content), 0600))
+	return configFile
+}
+
+func TestProfiles_CurrentAndInheritance(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	configFile := writeConfig(t, profilesYaml)
+
+	cfg, err := config.GetConfig(configFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, "openid", cfg.OsduClient.Provider)
+	assert.Equal(t, "datafier", cfg.OsduClient.ClientId)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+	assert.Equal(t, "group", cfg.OsduClient.EntitlementsDomain)
+	assert.Equal(t, "https://osdu-dev/api/partition/v1", cfg.OsduClient.PartitionUrl)
+
+	names, current, err := config.ListProfiles(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base", "dev", "prod"}, names)
+	assert.Equal(t, "dev", current)
+}
+
+func TestProfiles_Selection(t *testing.T) {
+	configFile := writeConfig(t, profilesYaml)
+
+	t.Setenv(config.ProfileEnv, "prod")
+	cfg, err := config.GetConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.OsduClient.PartitionId)
+	// Lists are replaced, not appended
+	assert.Equal(t, []string{"openid", "email"}, cfg.OsduClient.Scopes)
+	// Inherited from base
+	assert.Equal(t, "datafier", cfg.OsduClient.ClientId)
+
+	// The API argument wins over OSDU_PROFILE
+	cfg, err = config.GetConfigProfile(configFile, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+
+	_, err = config.GetConfigProfile(configFile, "staging")
+	assert.ErrorContains(t, err, `profile "staging" not found`)
+}
+
+func TestProfiles_LoadConfigWithEnvOverlay(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfig(t, profilesYaml))
+	t.Setenv(config.ProfileEnv, "")
+	t.Setenv("OSDU_CLIENT_PARTITION_ID", "overlay")
+
+	cfg, err := config.LoadConfigProfile("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "overlay", cfg.OsduClient.PartitionId)
+	assert.Equal(t, "https://osdu-prod/api/partition/v1", cfg.OsduClient.PartitionUrl)
+}
+
+func TestProfiles_InheritanceErrors(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+
+	_, err := config.GetConfigProfile(writeConfig(t, `
+profiles:
+  a:
+    inherits: b
+  b:
+    inherits: a
+`), "a")
+	assert.ErrorContains(t, err, "cycle")
+
+	_, err = config.GetConfigProfile(writeConfig(t, `
+profiles:
+  a:
+    inherits: missing
+`), "")
+	assert.ErrorContains(t, err, "unknown profile")
+
+	_, err = config.GetConfigProfile(writeConfig(t, `
+profiles:
+  a: {}
+  b: {}
+`), "")
+	assert.ErrorContains(t, err, "no profile selected")
+}
+
+func TestProfiles_PlainFile(t *testing.T) {
+	configFile := writeConfig(t, `
+osdu:
+  provider: openid
+  client:
+    partitionId: opendes
+`)
+
+	cfg, err := config.GetConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+
+	_, err = config.GetConfigProfile(configFile, "prod")
+	assert.ErrorContains(t, err, "has no profiles")
+}
+
+func TestProfiles_AzureExample(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+
+	cfg, err := config.GetConfig("../../config/azure-example.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "azure", cfg.OsduClient.Provider)
+	assert.Equal(t, "your-app-id", cfg.OsduClient.ClientId)
+	assert.False(t, cfg.OsduClient.SdkAuth)
+
+	cfg, err = config.GetConfigProfile("../../config/azure-example.yaml", "managed-identity")
+	require.NoError(t, err)
+	assert.True(t, cfg.OsduClient.SdkAuth)
+	assert.Equal(t, "your-tenant-id", cfg.OsduClient.TenantId)
+	assert.Equal(t, "opendes", cfg.OsduClient.PartitionId)
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Prefixes of secret references, allowed in fields tagged secret:"true"
+//
+//	clientSecret: env:OSDU_CLIENT_SECRET     # environment variable
+//	clientSecret: file:/var/run/secrets/osdu/client-secret
+//	clientSecret: dir:/var/run/secrets/osdu  # key named after the field
+const (
+	SecretRefEnv  = "env:"
+	SecretRefFile = "file:"
+	SecretRefDir  = "dir:"
+)
+
+// redactedSecret replaces resolved secrets when a configuration is printed
+const redactedSecret = "******"
+
+// SecretRefError reports a secret reference that could not be resolved
+type SecretRefError struct {
+	// Field is the YAML path of the field, i.e osdu.auth.clientSecret
+	Field string
+	// Ref is the reference, i.e file:/var/run/secrets/osdu/client-secret
+	Ref string
+	Err error
+}
+
+func (e *SecretRefError) Error() string {
+	return fmt.Sprintf("%s: cannot resolve secret reference %q: %s", e.Field, e.Ref, e.Err)
+}
+
+func (e *SecretRefError) Unwrap() error {
+	return e.Err
+}
+
+// IsSecretRef reports whether value is an env:, file: or dir: reference
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretRefEnv) ||
+		strings.HasPrefix(value, SecretRefFile) ||
+		strings.HasPrefix(value, SecretRefDir)
+}
+
+// ResolveSecrets replaces every secret reference of cfg by its value.
+// All failures are returned, each one as a *SecretRefError.
+func ResolveSecrets(cfg *Config) error {
+	_, err := resolveSecrets(cfg)
+	return err
+}
+
+// resolveSecrets is ResolveSecrets also returning the env: and file: sources that were read
+func resolveSecrets(cfg *Config) ([]string, error) {
+	var sources []string
+	var errs []error
+	walkSecrets(reflect.ValueOf(cfg).Elem(), nil, func(field reflect.Value, path []string) {
+		ref := field.String()
+		if !IsSecretRef(ref) {
+			return
+		}
+		value, source, err := resolveSecretRef(ref, path[len(path)-1])
+		if source != "" {
+			sources = append(sources, source)
+		}
+		if err != nil {
+			errs = append(errs, &SecretRefError{Field: strings.Join(path, "."), Ref: ref, Err: err})
+			return
+		}
+		field.SetString(value)
+	})
+	return sources, errors.Join(errs...)
+}
+
+// resolveSecretRef returns the secret and the env: or file: source it was read from
+func resolveSecretRef(ref string, fieldName string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(ref, SecretRefEnv):
+		name := strings.TrimPrefix(ref, SecretRefEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", ref, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, ref, nil
+	case strings.HasPrefix(ref, SecretRefFile):
+		value, err := readSecretFile(strings.TrimPrefix(ref, SecretRefFile))
+		return value, ref, err
+	case strings.HasPrefix(ref, SecretRefDir):
+		dir := strings.TrimPrefix(ref, SecretRefDir)
+		// Kubernetes secret keys are usually named clientSecret, client-secret or CLIENT_SECRET
+		snake := toScreamingSnake(fieldName)
+		keys := []string{fieldName, strings.ToLower(strings.ReplaceAll(snake, "_", "-")), snake}
+		for _, key := range keys {
+			path := filepath.Join(dir, key)
+			value, err := readSecretFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return value, SecretRefFile + path, err
+		}
+		return "", "", fmt.Errorf("none of %s found in %s", strings.Join(keys, ", "), dir)
+	}
+	return "", "", errors.New("unknown reference")
+}
+
+// readSecretFile reads a mounted secret, without the trailing newline
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// walkSecrets calls fn on every string field tagged secret:"true", with its YAML path.
+// Slices of structs are copied before being walked so the caller can modify a copy of a
+// configuration without touching the original.
+func walkSecrets(v reflect.Value, path []string, fn func(field reflect.Value, path []string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), name)
+		field := v.Field(i)
+
+		switch {
+		case f.Type.Kind() == reflect.String && f.Tag.Get("secret") == "true":
+			fn(field, fieldPath)
+		case f.Type.Kind() == reflect.Struct:
+			walkSecrets(field, fieldPath, fn)
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct && field.Len() > 0:
+			copied := reflect.MakeSlice(f.Type, field.Len(), field.Len())
+			reflect.Copy(copied, field)
+			field.Set(copied)
+			for j := 0; j < field.Len(); j++ {
+				elemPath := append([]string{}, fieldPath...)
+				elemPath[len(elemPath)-1] = fmt.Sprintf("%s[%d]", name, j)
+				walkSecrets(field.Index(j), elemPath, fn)
+			}
+		}
+	}
+}
+
+// redact masks the secret fields of the struct pointed by ptr, references are kept as they are not secret
+func redact(ptr interface{}) {
+	walkSecrets(reflect.ValueOf(ptr).Elem(), nil, func(field reflect.Value, _ []string) {
+		if value := field.String(); value != "" && !IsSecretRef(value) {
+			field.SetString(redactedSecret)
+		}
+	})
+}
+
+// Redacted returns a copy of the configuration with every secret masked
+func (c Config) Redacted() Config {
+	redact(&c)
+	return c
+}
+
+// String prints the configuration with every secret masked
+func (c Config) String() string {
+	type plain Config
+	redact(&c)
+	return fmt.Sprintf("%+v", plain(c))
+}
+
+// String prints the client configuration with every secret masked
+func (c OsduClient) String() string {
+	// The embedded AuthSettings.String would be promoted to a plain copy, fields are printed one by one
+	return fmt.Sprintf("{Provider:%s AuthSettings:%s OsduSettings:%+v}", c.Provider, c.AuthSettings, c.OsduSettings)
+}
+
+// String prints the auth settings with every secret masked
+func (a AuthSettings) String() string {
+	type plain AuthSettings
+	redact(&a)
+	return fmt.Sprintf("%+v", plain(a))
+}
+
+// String prints the chained provider with every secret masked
+func (c ChainedProvider) String() string {
+	return fmt.Sprintf("{Provider:%s AuthSettings:%s}", c.Provider, c.AuthSettings)
+}
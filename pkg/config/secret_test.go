@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	secrets := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(secrets, "client-secret"), []byte("from-dir\n"), 0600))
+	secretFile := filepath.Join(t.TempDir(), "refresh-token")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+	t.Setenv("TEST_CERT_PASSWORD", "from-env")
+
+	cfg := config.Config{}
+	cfg.OsduClient.ClientSecret = "dir:" + secrets
+	cfg.OsduClient.RefreshToken = "file:" + secretFile
+	cfg.OsduClient.CertificatePassword = "env:TEST_CERT_PASSWORD"
+	cfg.OsduClient.Chain = []config.ChainedProvider{
+		{Provider: "openid", AuthSettings: config.AuthSettings{ClientSecret: "env:TEST_CERT_PASSWORD"}},
+	}
+	// Fields without the secret tag are not resolved
+	cfg.OsduClient.ClientId = "env:TEST_CERT_PASSWORD"
+
+	require.NoError(t, config.ResolveSecrets(&cfg))
+	assert.Equal(t, "from-dir", cfg.OsduClient.ClientSecret)
+	assert.Equal(t, "from-file", cfg.OsduClient.RefreshToken)
+	assert.Equal(t, "from-env", cfg.OsduClient.CertificatePassword)
+	assert.Equal(t, "from-env", cfg.OsduClient.Chain[0].ClientSecret)
+	assert.Equal(t, "env:TEST_CERT_PASSWORD", cfg.OsduClient.ClientId)
+}
+
+func TestResolveSecrets_Errors(t *testing.T) {
+	cfg := config.Config{}
+	cfg.OsduClient.ClientSecret = "env:TEST_MISSING_SECRET"
+	cfg.OsduClient.RefreshToken = "dir:" + t.TempDir()
+	cfg.OsduClient.Chain = []config.ChainedProvider{
+		{AuthSettings: config.AuthSettings{ClientSecret: "file:/nonexistent/secret"}},
+	}
+
+	err := config.ResolveSecrets(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `osdu.auth.clientSecret: cannot resolve secret reference "env:TEST_MISSING_SECRET"`)
+	assert.Contains(t, err.Error(), "osdu.auth.refreshToken")
+	assert.Contains(t, err.Error(), "refreshToken, refresh-token, REFRESH_TOKEN")
+	assert.Contains(t, err.Error(), "osdu.auth.chain[0].auth.clientSecret")
+
+	var refErr *config.SecretRefError
+	require.True(t, errors.As(err, &refErr))
+	assert.Equal(t, "osdu.auth.clientSecret", refErr.Field)
+}
+
+func TestLoadConfig_ResolvesSecretsAfterEnv(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "client-secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("mounted-secret"), 0600))
+
+	t.Setenv("CONFIG_FILE", writeConfig(t, `
+osdu:
+  auth:
+    clientId: datafier
+    clientSecret: env:TEST_NOT_USED
+`))
+	t.Setenv("OSDU_AUTH_CLIENT_SECRET", "file:"+secretFile)
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "mounted-secret", cfg.OsduClient.ClientSecret)
+}
+
+func TestConfigString_RedactsSecrets(t *testing.T) {
+	cfg := config.Config{}
+	cfg.OsduClient.Provider = "chain"
+	cfg.OsduClient.ClientId = "datafier"
+	cfg.OsduClient.ClientSecret = "super-secret"
+	cfg.OsduClient.RefreshToken = "file:/var/run/secrets/refresh-token"
+	cfg.OsduClient.PartitionId = "opendes"
+	cfg.OsduClient.Chain = []config.ChainedProvider{
+		{Provider: "openid", AuthSettings: config.AuthSettings{ClientSecret: "chained-secret"}},
+	}
+
+	for _, printed := range []string{
+		cfg.String(),
+		fmt.Sprintf("%v", cfg),
+		fmt.Sprintf("%+v", cfg.OsduClient),
+		fmt.Sprint(cfg.OsduClient.AuthSettings),
+		fmt.Sprintf("%+v", cfg.Redacted()),
+	} {
+		assert.NotContains(t, printed, "super-secret")
+		assert.NotContains(t, printed, "chained-secret")
+		assert.Contains(t, printed, "******")
+		// References are not secret and help debugging
+		assert.Contains(t, printed, "file:/var/run/secrets/refresh-token")
+	}
+	assert.Equal(t, "{Provider:openid AuthSettings:", fmt.Sprint(cfg.OsduClient.Chain[0])[:30])
+	assert.NotContains(t, fmt.Sprint(cfg.OsduClient.Chain[0]), "chained-secret")
+	assert.Contains(t, cfg.String(), "opendes")
+	assert.Contains(t, cfg.OsduClient.String(), "Provider:chain")
+
+	// The original configuration is untouched
+	assert.Equal(t, "super-secret", cfg.OsduClient.ClientSecret)
+	assert.Equal(t, "chained-secret", cfg.OsduClient.Chain[0].ClientSecret)
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+)
+
+// Supported values for OsduClient.Provider, an empty provider is openid
+const (
+	ProviderOpenID = "openid"
+	ProviderAzure  = "azure"
+	ProviderChain  = "chain"
+)
+
+// FieldError is a single validation problem of a configuration field
+type FieldError struct {
+	// Field is the YAML path of the field, i.e osdu.client.partitionUrl
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors lists every problem found by Validate
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	problems := make([]string, 0, len(e))
+	for _, fe := range e {
+		problems = append(problems, fe.Error())
+	}
+	return fmt.Sprintf("invalid configuration (%d problems):\n  %s", len(e), strings.Join(problems, "\n  "))
+}
+
+// validator collects problems under a path prefix
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(field string, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(field string, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) url(field string, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add(field, "malformed URL %q: %s", value, err)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		v.add(field, "URL %q must use http or https", value)
+		return
+	}
+	if u.Host == "" {
+		v.add(field, "URL %q has no host", value)
+	}
+}
+
+func (v *validator) requiredUrl(field string, value string) {
+	if v.required(field, value) {
+		v.url(field, value)
+	}
+}
+
+// Validate checks the configuration and returns every problem at once as ValidationErrors
+func (c Config) Validate() error {
+	return c.OsduClient.Validate()
+}
+
+// Validate checks the provider, auth and client settings and returns every problem at once as ValidationErrors
+func (c OsduClient) Validate() error {
+	v := &validator{}
+	validateProvider(v, "osdu", c.Provider, c.AuthSettings)
+	validateOsduSettings(v, "osdu.client", c.OsduSettings, c.AuthSettings)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// PartitionTemplate returns the default partition template of the provider,
+// a chain uses the provider of its first entry
+func (c OsduClient) PartitionTemplate() (string, error) {
+	provider, chain := c.Provider, c.AuthSettings.Chain
+	for provider == ProviderChain && len(chain) > 0 {
+		provider, chain = chain[0].Provider, chain[0].AuthSettings.Chain
+	}
+	return models.PartitionTemplateForProvider(provider)
+}
+
+func validateOsduSettings(v *validator, path string, s OsduSettings, auth AuthSettings) {
+	// In internal mode the in-cluster URLs replace the configured ones
+	if auth.InternalService {
+		s = auth.Internal.ResolveUrls(s)
+	}
+
+	v.requiredUrl(path+".partitionUrl", s.PartitionUrl)
+	v.requiredUrl(path+".entitlementsUrl", s.EntitlementsUrl)
+	v.url(path+".workflowUrl", s.WorkflowUrl)
+	v.url(path+".schemaUrl", s.SchemaUrl)
+	v.url(path+".datasetUrl", s.DatasetUrl)
+
+	if v.required(path+".partitionId", s.PartitionId) && len(s.PartitionId) < 2 {
+		v.add(path+".partitionId", "%q is too short, at least 2 characters", s.PartitionId)
+	}
+
+	if s.PartitionOverrides != "" {
+		if _, err := models.LoadPartitionOverrides(s.PartitionOverrides); err != nil {
+			v.add(path+".partitionOverrides", "%s", err)
+		}
+	}
+
+	bootstrap := s.EntitlementsBootstrap
+	mappings := make([]models.EntitlementsBoostrapUser, 0, len(bootstrap.AliasMappings))
+	for _, mapping := range bootstrap.AliasMappings {
+		mappings = append(mappings, models.EntitlementsBoostrapUser{AliasId: mapping.AliasId, UserId: mapping.UserId})
+	}
+	if _, err := models.ResolveEntitlementsBootstrapUsers(bootstrap.Replace, bootstrap.File, mappings); err != nil {
+		v.add(path+".entitlementsBootstrap", "%s", err)
+	}
+}
+
+func validateProvider(v *validator, path string, provider string, auth AuthSettings) {
+	authPath := path + ".auth"
+
+	switch provider {
+	case "", ProviderOpenID:
+		validateOpenID(v, authPath, auth)
+	case ProviderAzure:
+		validateAzure(v, authPath, auth)
+	case ProviderChain:
+		if len(auth.Chain) == 0 {
+			v.add(authPath+".chain", "provider %s requires at least one entry", ProviderChain)
+		}
+		for i, entry := range auth.Chain {
+			validateProvider(v, fmt.Sprintf("%s.chain[%d]", authPath, i), entry.Provider, entry.AuthSettings)
+		}
+	default:
+		v.add(path+".provider", "unknown provider %q, expected one of %s, %s, %s",
+			provider, ProviderOpenID, ProviderAzure, ProviderChain)
+	}
+}
+
+func validateOpenID(v *validator, path string, auth AuthSettings) {
+	v.required(path+".clientId", auth.ClientId)
+	v.requiredUrl(path+".tokenUrl", auth.TokenUrl)
+	if v.required(path+".grantType", auth.GrantType) && auth.GrantType == "refresh_token" {
+		v.required(path+".refreshToken", auth.RefreshToken)
+	}
+}
+
+func validateAzure(v *validator, path string, auth AuthSettings) {
+	v.url(path+".authorityHost", auth.AuthorityHost)
+
+	mode := auth.AzureCredential
+	if mode == "" && auth.SdkAuth {
+		mode = AzureCredentialDefault
+	}
+	if mode == "" && auth.ClientId != "" && auth.ClientSecret != "" && auth.TenantId != "" {
+		mode = AzureCredentialClientSecret
+	}
+
+	switch mode {
+	case "":
+		// OAuth2 fallback against tokenUrl
+		validateOpenID(v, path, auth)
+	case AzureCredentialDefault, AzureCredentialAzureCLI, AzureCredentialWorkloadIdentity:
+		// Resolved from the environment (AZURE_* variables, az login, ...)
+	case AzureCredentialClientSecret:
+		v.required(path+".tenantId", auth.TenantId)
+		v.required(path+".clientId", auth.ClientId)
+		v.required(path+".clientSecret", auth.ClientSecret)
+	case AzureCredentialManagedIdentity:
+		if auth.ManagedIdentityClientId != "" && auth.ManagedIdentityResourceId != "" {
+			v.add(path+".managedIdentityResourceId", "managedIdentityClientId and managedIdentityResourceId are mutually exclusive")
+		}
+	case AzureCredentialClientCertificate:
+		v.required(path+".tenantId", auth.TenantId)
+		v.required(path+".clientId", auth.ClientId)
+		v.required(path+".certificatePath", auth.CertificatePath)
+	default:
+		v.add(path+".azureCredential", "unknown azure credential %q", mode)
+	}
+}
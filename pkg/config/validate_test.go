@@ -0,0 +1,163 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() config.Config {
+	return config.Config{OsduClient: config.OsduClient{
+		Provider: config.ProviderOpenID,
+		AuthSettings: config.AuthSettings{
+			ClientId:  "datafier",
+			TokenUrl:  "https://keycloak/realms/osdu/protocol/openid-connect/token",
+			GrantType: "client_credentials",
+		},
+		OsduSettings: config.OsduSettings{
+			PartitionId:     "opendes",
+			PartitionUrl:    "https://osdu/api/partition/v1",
+			EntitlementsUrl: "https://osdu/api/entitlements/v2",
+		},
+	}}
+}
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	var verrs config.ValidationErrors
+	require.True(t, errors.As(err, &verrs), "expected ValidationErrors, got %v", err)
+	names := []string{}
+	for _, fe := range verrs {
+		names = append(names, fe.Field)
+	}
+	return names
+}
+
+func TestValidate_Valid(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidate_DefaultConfigFile(t *testing.T) {
+	cfg, err := config.GetConfig("../../config/default.yaml")
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_AllProblemsAtOnce(t *testing.T) {
+	cfg := validConfig()
+	cfg.OsduClient.PartitionUrl = ""
+	cfg.OsduClient.EntitlementsUrl = "osdu/api/entitlements/v2"
+	cfg.OsduClient.WorkflowUrl = "ftp://osdu/api/workflow/v1"
+	cfg.OsduClient.PartitionId = "o"
+	cfg.OsduClient.TokenUrl = ""
+
+	err := cfg.Validate()
+	assert.ElementsMatch(t, []string{
+		"osdu.client.partitionUrl",
+		"osdu.client.entitlementsUrl",
+		"osdu.client.workflowUrl",
+		"osdu.client.partitionId",
+		"osdu.auth.tokenUrl",
+	}, fields(t, err))
+	assert.Contains(t, err.Error(), "5 problems")
+}
+
+func TestValidate_Providers(t *testing.T) {
+	cfg := validConfig()
+	cfg.OsduClient.Provider = "keycloak"
+	assert.Equal(t, []string{"osdu.provider"}, fields(t, cfg.Validate()))
+
+	cfg = validConfig()
+	cfg.OsduClient.GrantType = "refresh_token"
+	assert.Equal(t, []string{"osdu.auth.refreshToken"}, fields(t, cfg.Validate()))
+
+	cfg = validConfig()
+	cfg.OsduClient.Provider = config.ProviderAzure
+	cfg.OsduClient.AzureCredential = config.AzureCredentialClientCertificate
+	assert.ElementsMatch(t, []string{"osdu.auth.tenantId", "osdu.auth.certificatePath"}, fields(t, cfg.Validate()))
+
+	cfg = validConfig()
+	cfg.OsduClient.Provider = config.ProviderAzure
+	cfg.OsduClient.SdkAuth = true
+	cfg.OsduClient.TokenUrl = ""
+	assert.NoError(t, cfg.Validate())
+
+	cfg = validConfig()
+	cfg.OsduClient.Provider = config.ProviderChain
+	cfg.OsduClient.Chain = []config.ChainedProvider{
+		{Provider: config.ProviderAzure, AuthSettings: config.AuthSettings{AzureCredential: config.AzureCredentialAzureCLI}},
+		{Provider: config.ProviderOpenID},
+	}
+	assert.ElementsMatch(t, []string{
+		"osdu.auth.chain[1].auth.clientId",
+		"osdu.auth.chain[1].auth.tokenUrl",
+		"osdu.auth.chain[1].auth.grantType",
+	}, fields(t, cfg.Validate()))
+}
+
+func TestValidate_InternalServiceUrls(t *testing.T) {
+	cfg := validConfig()
+	cfg.OsduClient.PartitionUrl = ""
+	cfg.OsduClient.EntitlementsUrl = ""
+	cfg.OsduClient.InternalService = true
+	cfg.OsduClient.Internal = config.InternalSettings{Namespace: "osdu"}
+
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_PartitionOverrides(t *testing.T) {
+	overridesFile := filepath.Join(t.TempDir(), "overrides.yaml")
+	require.NoError(t, os.WriteFile(overridesFile, []byte("properties:\n  bucket:\n    value: b\n    sensitiv: true\n"), 0600))
+
+	cfg := validConfig()
+	cfg.OsduClient.OsduSettings.PartitionOverrides = overridesFile
+	assert.Equal(t, []string{"osdu.client.partitionOverrides"}, fields(t, cfg.Validate()))
+
+	require.NoError(t, os.WriteFile(overridesFile, []byte("properties:\n  bucket: b\n"), 0600))
+	assert.NoError(t, cfg.Validate())
+
+	cfg.OsduClient.OsduSettings.PartitionOverrides = filepath.Join(t.TempDir(), "missing.yaml")
+	assert.Equal(t, []string{"osdu.client.partitionOverrides"}, fields(t, cfg.Validate()))
+}
+
+func TestValidate_EntitlementsBootstrap(t *testing.T) {
+	cfg := validConfig()
+	cfg.OsduClient.OsduSettings.EntitlementsBootstrap.AliasMappings = []config.EntitlementsAliasMapping{
+		{AliasId: "SERVICE_PRINCIPAL_UNKNOWN", UserId: "unknown@service.local"},
+	}
+	assert.Equal(t, []string{"osdu.client.entitlementsBootstrap"}, fields(t, cfg.Validate()))
+
+	cfg.OsduClient.OsduSettings.EntitlementsBootstrap = config.EntitlementsBootstrapSettings{Replace: true}
+	assert.Equal(t, []string{"osdu.client.entitlementsBootstrap"}, fields(t, cfg.Validate()))
+
+	cfg.OsduClient.OsduSettings.EntitlementsBootstrap.File = filepath.Join(t.TempDir(), "missing.yaml")
+	assert.Equal(t, []string{"osdu.client.entitlementsBootstrap"}, fields(t, cfg.Validate()))
+}
+
+func TestOsduClient_PartitionTemplate(t *testing.T) {
+	cfg := validConfig()
+	template, err := cfg.OsduClient.PartitionTemplate()
+	assert.NoError(t, err)
+	assert.Equal(t, models.PartitionTemplateCI, template)
+
+	cfg.OsduClient.Provider = config.ProviderAzure
+	template, err = cfg.OsduClient.PartitionTemplate()
+	assert.NoError(t, err)
+	assert.Equal(t, models.PartitionTemplateAzure, template)
+
+	cfg.OsduClient.Provider = config.ProviderChain
+	cfg.OsduClient.AuthSettings.Chain = []config.ChainedProvider{{Provider: config.ProviderAzure}, {Provider: config.ProviderOpenID}}
+	template, err = cfg.OsduClient.PartitionTemplate()
+	assert.NoError(t, err)
+	assert.Equal(t, models.PartitionTemplateAzure, template)
+
+	cfg.OsduClient.Provider = "kerberos"
+	_, err = cfg.OsduClient.PartitionTemplate()
+	assert.ErrorContains(t, err, `provider "kerberos" has no partition template`)
+}
@@ -1,12 +1,12 @@
 package models
 
 type EntitlementsBoostrapUser struct {
-	AliasId string `json:"aliasId"`
-	UserId  string `json:"userId"`
+	AliasId string `json:"aliasId" yaml:"aliasId"`
+	UserId  string `json:"userId" yaml:"userId"`
 }
 
 type EntitlementsBootstrapRequest struct {
-	AliasMappings []EntitlementsBoostrapUser `json:"aliasMappings"`
+	AliasMappings []EntitlementsBoostrapUser `json:"aliasMappings" yaml:"aliasMappings"`
 }
 
 type EntitlementsAddUserRequest struct {
@@ -19,10 +19,88 @@ type EntitlementsCreateGroupRequest struct {
 	Description string `json:"description"`
 }
 
+// Roles of a group member
+const (
+	EntitlementsRoleOwner  = "OWNER"
+	EntitlementsRoleMember = "MEMBER"
+)
+
+// Types of groups of GET /members/{email}/groups
+const (
+	EntitlementsGroupTypeNone    = "NONE"
+	EntitlementsGroupTypeData    = "DATA"
+	EntitlementsGroupTypeUser    = "USER"
+	EntitlementsGroupTypeService = "SERVICE"
+)
+
+type EntitlementsGroup struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Email       string `json:"email"`
+}
+
+// EntitlementsGroupsResponse is the response of GET /groups and GET /members/{email}/groups
+type EntitlementsGroupsResponse struct {
+	DesId       string              `json:"desId"`
+	MemberEmail string              `json:"memberEmail"`
+	Groups      []EntitlementsGroup `json:"groups"`
+}
+
+type EntitlementsMember struct {
+	Email      string `json:"email"`
+	Role       string `json:"role"`
+	MemberType string `json:"memberType,omitempty"`
+}
+
+// EntitlementsMembersResponse is a page of GET /groups/{email}/members, Cursor is empty on the last page
+type EntitlementsMembersResponse struct {
+	Members []EntitlementsMember `json:"members"`
+	Cursor  string               `json:"cursor,omitempty"`
+}
+
+// EntitlementsListMembersOptions filters and pages GET /groups/{email}/members
+type EntitlementsListMembersOptions struct {
+	// Role keeps the OWNER or MEMBER members only, all members when empty
+	Role string
+	// Limit is the size of a page, the service default when zero
+	Limit int
+	// Cursor is the page to read, the first one when empty
+	Cursor string
+}
+
+// EntitlementsGroupPatchOperation is an operation of PATCH /groups/{email}
+type EntitlementsGroupPatchOperation struct {
+	Op    string   `json:"op"`
+	Path  string   `json:"path"`
+	Value []string `json:"value"`
+}
+
+// EntitlementsGroupUpdate lists the group fields to update, nil fields are left unchanged
+type EntitlementsGroupUpdate struct {
+	Description *string
+	// AppIds replaces the app ids allowed to use the group, an empty slice allows every app
+	AppIds []string
+}
+
+// Operations returns the PATCH operations of the update
+func (u EntitlementsGroupUpdate) Operations() []EntitlementsGroupPatchOperation {
+	var operations []EntitlementsGroupPatchOperation
+	if u.Description != nil {
+		operations = append(operations, EntitlementsGroupPatchOperation{
+			Op: "replace", Path: "/description", Value: []string{*u.Description}})
+	}
+	if u.AppIds != nil {
+		operations = append(operations, EntitlementsGroupPatchOperation{
+			Op: "replace", Path: "/appIds", Value: u.AppIds})
+	}
+	return operations
+}
+
 /*
 https://community.opengroup.org/osdu/platform/security-and-compliance/entitlements/-/blob/release/0.27/provider/entitlements-v2-jdbc/bootstrap/bootstrap.sh?ref_type=heads
 */
 
+// DefaultEntitlementsBootstrapUsers returns the core-plus alias mappings, sorted
 func DefaultEntitlementsBootstrapUsers() []EntitlementsBoostrapUser {
 	extra_service_principals := []string{
 		"datafier@service.local",
@@ -36,13 +114,13 @@ func DefaultEntitlementsBootstrapUsers() []EntitlementsBoostrapUser {
 	*/
 
 	m := make(map[string]string)
-	m["SERVICE_PRINCIPAL_AIRFLOW"] = "airflow@service.local"
-	m["SERVICE_PRINCIPAL_INDEXER"] = "indexer@service.local"
-	m["SERVICE_PRINCIPAL_GCZ"] = "gcz-transformer@service.local"
-	m["SERVICE_PRINCIPAL_REGISTER"] = "register@service.local"
-	m["SERVICE_PRINCIPAL_NOTIFICATION"] = "notification@service.local"
-	m["SERVICE_PRINCIPAL_STORAGE"] = "storage@service.local"
-	m["SERVICE_PRINCIPAL_SEISMIC"] = "seismic@service.local"
+	m[EntitlementsAliasAirflow] = "airflow@service.local"
+	m[EntitlementsAliasIndexer] = "indexer@service.local"
+	m[EntitlementsAliasGcz] = "gcz-transformer@service.local"
+	m[EntitlementsAliasRegister] = "register@service.local"
+	m[EntitlementsAliasNotification] = "notification@service.local"
+	m[EntitlementsAliasStorage] = "storage@service.local"
+	m[EntitlementsAliasSeismic] = "seismic@service.local"
 
 	var bootstrap_users []EntitlementsBoostrapUser
 
@@ -55,10 +133,11 @@ func DefaultEntitlementsBootstrapUsers() []EntitlementsBoostrapUser {
 
 	for _, sp := range extra_service_principals {
 		bootstrap_users = append(bootstrap_users, EntitlementsBoostrapUser{
-			AliasId: "SERVICE_PRINCIPAL",
+			AliasId: EntitlementsAliasServicePrincipal,
 			UserId:  sp,
 		})
 	}
 
+	SortEntitlementsBootstrapUsers(bootstrap_users)
 	return bootstrap_users
 }
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Aliases of the tenant provisioning, one per accounts file of the entitlements service
+// (groups_of_<alias>.json)
+const (
+	EntitlementsAliasServicePrincipal = "SERVICE_PRINCIPAL"
+	EntitlementsAliasAirflow          = "SERVICE_PRINCIPAL_AIRFLOW"
+	EntitlementsAliasGcz              = "SERVICE_PRINCIPAL_GCZ"
+	EntitlementsAliasIndexer          = "SERVICE_PRINCIPAL_INDEXER"
+	EntitlementsAliasNotification     = "SERVICE_PRINCIPAL_NOTIFICATION"
+	EntitlementsAliasRegister         = "SERVICE_PRINCIPAL_REGISTER"
+	EntitlementsAliasSeismic          = "SERVICE_PRINCIPAL_SEISMIC"
+	EntitlementsAliasStorage          = "SERVICE_PRINCIPAL_STORAGE"
+)
+
+// entitlementsBootstrapAliases lists the known aliases, true when the alias maps several users
+var entitlementsBootstrapAliases = map[string]bool{
+	EntitlementsAliasServicePrincipal: true,
+	EntitlementsAliasAirflow:          false,
+	EntitlementsAliasGcz:              false,
+	EntitlementsAliasIndexer:          false,
+	EntitlementsAliasNotification:     false,
+	EntitlementsAliasRegister:         false,
+	EntitlementsAliasSeismic:          false,
+	EntitlementsAliasStorage:          false,
+}
+
+// entitlementsPrincipalId matches an email or the object id of an Azure principal
+var entitlementsPrincipalId = regexp.MustCompile(`^([^@\s]+@[^@\s]+\.[^@\s]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// EntitlementsBootstrapAliases returns the known aliases, sorted
+func EntitlementsBootstrapAliases() []string {
+	aliases := make([]string, 0, len(entitlementsBootstrapAliases))
+	for alias := range entitlementsBootstrapAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// IsKnownEntitlementsBootstrapAlias reports whether alias is provisioned by the entitlements service
+func IsKnownEntitlementsBootstrapAlias(alias string) bool {
+	_, ok := entitlementsBootstrapAliases[alias]
+	return ok
+}
+
+// SortEntitlementsBootstrapUsers sorts users by alias then user id, so the bootstrap request
+// is the same from one run to the other
+func SortEntitlementsBootstrapUsers(users []EntitlementsBoostrapUser) {
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].AliasId != users[j].AliasId {
+			return users[i].AliasId < users[j].AliasId
+		}
+		return users[i].UserId < users[j].UserId
+	})
+}
+
+// ValidateEntitlementsBootstrapUsers reports every unknown alias, malformed user id (an email
+// or an Azure object id) and alias mapped to several users when it takes only one
+func ValidateEntitlementsBootstrapUsers(users []EntitlementsBoostrapUser) error {
+	var errs []error
+	mapped := map[string]string{}
+	for i, user := range users {
+		multiple, ok := entitlementsBootstrapAliases[user.AliasId]
+		if !ok {
+			errs = append(errs, fmt.Errorf("aliasMappings[%d]: unknown alias %q, expected one of %s",
+				i, user.AliasId, strings.Join(EntitlementsBootstrapAliases(), ", ")))
+		}
+		if !entitlementsPrincipalId.MatchString(user.UserId) {
+			errs = append(errs, fmt.Errorf("aliasMappings[%d]: malformed user id %q, expected an email or an object id", i, user.UserId))
+		}
+		if !ok || multiple {
+			continue
+		}
+		if previous, ok := mapped[user.AliasId]; ok && previous != user.UserId {
+			errs = append(errs, fmt.Errorf("aliasMappings[%d]: alias %s is already mapped to %s", i, user.AliasId, previous))
+		}
+		mapped[user.AliasId] = user.UserId
+	}
+	return errors.Join(errs...)
+}
+
+// MergeEntitlementsBootstrapUsers returns base with overrides applied, sorted and without
+// duplicates. An alias taking a single user is replaced, the users of SERVICE_PRINCIPAL are added.
+func MergeEntitlementsBootstrapUsers(base []EntitlementsBoostrapUser, overrides []EntitlementsBoostrapUser) []EntitlementsBoostrapUser {
+	replaced := map[string]bool{}
+	for _, user := range overrides {
+		if !entitlementsBootstrapAliases[user.AliasId] {
+			replaced[user.AliasId] = true
+		}
+	}
+
+	seen := map[EntitlementsBoostrapUser]bool{}
+	merged := []EntitlementsBoostrapUser{}
+	add := func(user EntitlementsBoostrapUser) {
+		if !seen[user] {
+			seen[user] = true
+			merged = append(merged, user)
+		}
+	}
+	for _, user := range base {
+		if !replaced[user.AliasId] {
+			add(user)
+		}
+	}
+	for _, user := range overrides {
+		add(user)
+	}
+
+	SortEntitlementsBootstrapUsers(merged)
+	return merged
+}
+
+// ResolveEntitlementsBootstrapUsers returns the alias mappings of the tenant provisioning, validated
+// and sorted: the defaults unless replace, then the mappings of file, then mappings
+func ResolveEntitlementsBootstrapUsers(replace bool, file string, mappings []EntitlementsBoostrapUser) ([]EntitlementsBoostrapUser, error) {
+	var users []EntitlementsBoostrapUser
+	if !replace {
+		users = DefaultEntitlementsBootstrapUsers()
+	}
+	if file != "" {
+		file_users, err := LoadEntitlementsBootstrapUsers(file)
+		if err != nil {
+			return nil, err
+		}
+		users = MergeEntitlementsBootstrapUsers(users, file_users)
+	}
+	if err := ValidateEntitlementsBootstrapUsers(mappings); err != nil {
+		return nil, err
+	}
+	users = MergeEntitlementsBootstrapUsers(users, mappings)
+
+	if len(users) == 0 {
+		return nil, errors.New("no alias mappings, set aliasMappings or a file when replace is true")
+	}
+	return users, nil
+}
+
+// LoadEntitlementsBootstrapUsers reads the alias mappings of a YAML or JSON file, in the format
+// of the tenant provisioning request
+//
+//	aliasMappings:
+//	  - aliasId: SERVICE_PRINCIPAL_AIRFLOW
+//	    userId: airflow@opendes.com
+func LoadEntitlementsBootstrapUsers(path string) ([]EntitlementsBoostrapUser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file EntitlementsBootstrapRequest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("entitlements bootstrap %s: %w", path, err)
+	}
+	if err := ValidateEntitlementsBootstrapUsers(file.AliasMappings); err != nil {
+		return nil, fmt.Errorf("entitlements bootstrap %s: %w", path, err)
+	}
+	return file.AliasMappings, nil
+}
@@ -0,0 +1,334 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Types of EntitlementsMember.MemberType and of EntitlementsGraphNode
+const (
+	EntitlementsMemberTypeUser  = "USER"
+	EntitlementsMemberTypeGroup = "GROUP"
+)
+
+// EntitlementsGraphNode is a user or a group of the graph
+type EntitlementsGraphNode struct {
+	Email string `json:"email"`
+	Type  string `json:"type"`
+}
+
+// EntitlementsGraphEdge is a direct membership: Member is in Group with Role, Role is empty
+// when the crawl did not read it
+type EntitlementsGraphEdge struct {
+	Member string `json:"member"`
+	Group  string `json:"group"`
+	Role   string `json:"role,omitempty"`
+}
+
+// EntitlementsEffectiveMembership is a transitive membership. Via lists the groups between
+// Member and Group, from the one Member is directly in, and is empty for a direct membership.
+type EntitlementsEffectiveMembership struct {
+	Member string   `json:"member"`
+	Group  string   `json:"group"`
+	Via    []string `json:"via,omitempty"`
+}
+
+// EntitlementsGraph is the membership graph crawled from Root. Emails are lowercase.
+type EntitlementsGraph struct {
+	Root  string
+	nodes map[string]string
+	// members and groups index the edges by group and by member
+	members map[string]map[string]string
+	groups  map[string]map[string]string
+}
+
+// NewEntitlementsGraph returns an empty graph crawled from root
+func NewEntitlementsGraph(root string, root_type string) *EntitlementsGraph {
+	g := &EntitlementsGraph{
+		nodes:   map[string]string{},
+		members: map[string]map[string]string{},
+		groups:  map[string]map[string]string{},
+	}
+	g.Root = strings.ToLower(root)
+	g.AddNode(root, root_type)
+	return g
+}
+
+// AddNode adds email with node_type, a node already known as a group stays a group
+func (g *EntitlementsGraph) AddNode(email string, node_type string) {
+	email = strings.ToLower(email)
+	if g.nodes[email] == EntitlementsMemberTypeGroup {
+		return
+	}
+	if node_type != EntitlementsMemberTypeGroup {
+		node_type = EntitlementsMemberTypeUser
+	}
+	g.nodes[email] = node_type
+}
+
+// AddEdge records that member is directly in group with role
+func (g *EntitlementsGraph) AddEdge(member string, member_type string, group string, role string) {
+	member, group = strings.ToLower(member), strings.ToLower(group)
+	g.AddNode(member, member_type)
+	g.AddNode(group, EntitlementsMemberTypeGroup)
+
+	if g.members[group] == nil {
+		g.members[group] = map[string]string{}
+	}
+	if g.groups[member] == nil {
+		g.groups[member] = map[string]string{}
+	}
+	if role == "" {
+		role = g.members[group][member]
+	}
+	g.members[group][member] = role
+	g.groups[member][group] = role
+}
+
+// Nodes returns the nodes sorted by email
+func (g *EntitlementsGraph) Nodes() []EntitlementsGraphNode {
+	nodes := make([]EntitlementsGraphNode, 0, len(g.nodes))
+	for _, email := range sortedKeys(g.nodes) {
+		nodes = append(nodes, EntitlementsGraphNode{Email: email, Type: g.nodes[email]})
+	}
+	return nodes
+}
+
+// Edges returns the direct memberships sorted by group then member
+func (g *EntitlementsGraph) Edges() []EntitlementsGraphEdge {
+	var edges []EntitlementsGraphEdge
+	for _, group := range sortedKeys(g.members) {
+		for _, member := range sortedKeys(g.members[group]) {
+			edges = append(edges, EntitlementsGraphEdge{Member: member, Group: group, Role: g.members[group][member]})
+		}
+	}
+	return edges
+}
+
+// IsGroup reports whether email is a group of the graph
+func (g *EntitlementsGraph) IsGroup(email string) bool {
+	return g.nodes[strings.ToLower(email)] == EntitlementsMemberTypeGroup
+}
+
+// EffectiveMembers returns the users in group directly or through nested groups, sorted, with
+// the shortest chain of groups
+func (g *EntitlementsGraph) EffectiveMembers(group string) []EntitlementsEffectiveMembership {
+	group = strings.ToLower(group)
+	var effective []EntitlementsEffectiveMembership
+	for _, reached := range g.walk(group, g.members) {
+		if g.nodes[reached.email] == EntitlementsMemberTypeGroup {
+			continue
+		}
+		effective = append(effective, EntitlementsEffectiveMembership{
+			Member: reached.email, Group: group, Via: reverse(reached.via)})
+	}
+	sort.Slice(effective, func(i, j int) bool { return effective[i].Member < effective[j].Member })
+	return effective
+}
+
+// EffectiveGroups returns the groups member is in directly or through nested groups, sorted,
+// with the shortest chain of groups
+func (g *EntitlementsGraph) EffectiveGroups(member string) []EntitlementsEffectiveMembership {
+	member = strings.ToLower(member)
+	var effective []EntitlementsEffectiveMembership
+	for _, reached := range g.walk(member, g.groups) {
+		effective = append(effective, EntitlementsEffectiveMembership{
+			Member: member, Group: reached.email, Via: reached.via})
+	}
+	sort.Slice(effective, func(i, j int) bool { return effective[i].Group < effective[j].Group })
+	return effective
+}
+
+type reachedNode struct {
+	email string
+	// via lists the nodes between the start and email, in the order of the walk
+	via []string
+}
+
+// walk returns the nodes reachable from start following next breadth first, every node once
+func (g *EntitlementsGraph) walk(start string, next map[string]map[string]string) []reachedNode {
+	visited := map[string]bool{start: true}
+	queue := []reachedNode{{email: start}}
+	var reached []reachedNode
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		var via []string
+		if current.email != start {
+			via = append(append([]string{}, current.via...), current.email)
+		}
+		for _, email := range sortedKeys(next[current.email]) {
+			if visited[email] {
+				continue
+			}
+			visited[email] = true
+			node := reachedNode{email: email, via: via}
+			reached = append(reached, node)
+			queue = append(queue, node)
+		}
+	}
+	return reached
+}
+
+// Cycles returns the cycles of group memberships found by a depth first walk, one per loop
+// back, so every group caught in a loop is in at least one cycle. A cycle starts at its
+// smallest group and is listed in membership order: a is in b, b is in a gives [a b].
+func (g *EntitlementsGraph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	seen := map[string]bool{}
+	var cycles [][]string
+
+	var visit func(email string)
+	visit = func(email string) {
+		state[email] = inProgress
+		stack = append(stack, email)
+		for _, group := range sortedKeys(g.groups[email]) {
+			switch state[group] {
+			case unvisited:
+				visit(group)
+			case inProgress:
+				start := len(stack) - 1
+				for stack[start] != group {
+					start--
+				}
+				cycle := normalizeCycle(stack[start:])
+				if key := strings.Join(cycle, " "); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[email] = done
+	}
+	for _, email := range sortedKeys(g.nodes) {
+		if state[email] == unvisited {
+			visit(email)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return strings.Join(cycles[i], " ") < strings.Join(cycles[j], " ") })
+	return cycles
+}
+
+// normalizeCycle rotates cycle to start at its smallest email
+func normalizeCycle(cycle []string) []string {
+	smallest := 0
+	for i, email := range cycle {
+		if email < cycle[smallest] {
+			smallest = i
+		}
+	}
+	return append(append([]string{}, cycle[smallest:]...), cycle[:smallest]...)
+}
+
+// EntitlementsGraphReport is the JSON export of a graph. Effective lists the effective members
+// of a root group, or the effective groups of a root user.
+type EntitlementsGraphReport struct {
+	Root      string                            `json:"root"`
+	Nodes     []EntitlementsGraphNode           `json:"nodes"`
+	Edges     []EntitlementsGraphEdge           `json:"edges"`
+	Cycles    [][]string                        `json:"cycles"`
+	Effective []EntitlementsEffectiveMembership `json:"effective"`
+}
+
+// Report returns the graph with its cycles and the effective memberships of the root
+func (g *EntitlementsGraph) Report() EntitlementsGraphReport {
+	report := EntitlementsGraphReport{
+		Root:   g.Root,
+		Nodes:  g.Nodes(),
+		Edges:  g.Edges(),
+		Cycles: g.Cycles(),
+	}
+	if g.IsGroup(g.Root) {
+		report.Effective = g.EffectiveMembers(g.Root)
+	} else {
+		report.Effective = g.EffectiveGroups(g.Root)
+	}
+	if report.Edges == nil {
+		report.Edges = []EntitlementsGraphEdge{}
+	}
+	if report.Cycles == nil {
+		report.Cycles = [][]string{}
+	}
+	if report.Effective == nil {
+		report.Effective = []EntitlementsEffectiveMembership{}
+	}
+	return report
+}
+
+// JSON returns the report as indented JSON
+func (g *EntitlementsGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g.Report(), "", "  ")
+}
+
+// DOT returns the graph in Graphviz format, an edge goes from the member to the group. Groups
+// are boxes, the root is bold and the edges of cycles are red.
+//
+//	dot -Tsvg entitlements.dot -o entitlements.svg
+func (g *EntitlementsGraph) DOT() string {
+	in_cycle := map[[2]string]bool{}
+	for _, cycle := range g.Cycles() {
+		for i, email := range cycle {
+			in_cycle[[2]string{email, cycle[(i+1)%len(cycle)]}] = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph entitlements {\n  rankdir=LR;\n")
+	for _, node := range g.Nodes() {
+		attributes := []string{"shape=ellipse"}
+		if node.Type == EntitlementsMemberTypeGroup {
+			attributes[0] = "shape=box"
+		}
+		if node.Email == g.Root {
+			attributes = append(attributes, "style=bold")
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", node.Email, strings.Join(attributes, ", "))
+	}
+	for _, edge := range g.Edges() {
+		var attributes []string
+		if edge.Role != "" {
+			attributes = append(attributes, fmt.Sprintf("label=%q", edge.Role))
+		}
+		if in_cycle[[2]string{edge.Member, edge.Group}] {
+			attributes = append(attributes, "color=red")
+		}
+		if len(attributes) == 0 {
+			fmt.Fprintf(&b, "  %q -> %q;\n", edge.Member, edge.Group)
+			continue
+		}
+		fmt.Fprintf(&b, "  %q -> %q [%s];\n", edge.Member, edge.Group, strings.Join(attributes, ", "))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func reverse(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	reversed := make([]string, 0, len(s))
+	for i := len(s) - 1; i >= 0; i-- {
+		reversed = append(reversed, s[i])
+	}
+	return reversed
+}
@@ -0,0 +1,329 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Kinds of EntitlementsAction, applied in this order
+const (
+	EntitlementsActionCreateGroup  = "create-group"
+	EntitlementsActionUpdateGroup  = "update-group"
+	EntitlementsActionAddMember    = "add-member"
+	EntitlementsActionChangeRole   = "change-role"
+	EntitlementsActionRemoveMember = "remove-member"
+)
+
+// DefaultEntitlementsProtectedGroups are never pruned: the root users group and the service
+// principal groups
+var DefaultEntitlementsProtectedGroups = []string{"users", "service.*"}
+
+// EntitlementsState is the desired group structure of a partition, as kept in git
+//
+//	partitionId: opendes
+//	groups:
+//	  - name: data.default.viewers
+//	    description: Default data viewers
+//	    members:
+//	      - email: admin@opendes.com
+//	        role: OWNER
+//	      - email: users.datalake.viewers@opendes.group
+type EntitlementsState struct {
+	// PartitionId is checked against the client partition when set
+	PartitionId string                   `yaml:"partitionId" json:"partitionId,omitempty"`
+	Groups      []EntitlementsStateGroup `yaml:"groups" json:"groups"`
+}
+
+// EntitlementsStateGroup is a desired group, its description is left unchanged when empty
+type EntitlementsStateGroup struct {
+	Name        string                    `yaml:"name" json:"name"`
+	Description string                    `yaml:"description,omitempty" json:"description,omitempty"`
+	Members     []EntitlementsStateMember `yaml:"members,omitempty" json:"members,omitempty"`
+}
+
+// EntitlementsStateMember is a desired member, MEMBER when the role is empty
+type EntitlementsStateMember struct {
+	Email string `yaml:"email" json:"email"`
+	Role  string `yaml:"role,omitempty" json:"role,omitempty"`
+}
+
+// EntitlementsLiveGroup is the live state of a desired group
+type EntitlementsLiveGroup struct {
+	Name   string
+	Exists bool
+	// Description is nil when unknown, the caller only sees the descriptions of its groups
+	Description *string
+	Members     []EntitlementsMember
+}
+
+// EntitlementsPlanOptions configures PlanEntitlements
+type EntitlementsPlanOptions struct {
+	// Prune removes the members of the desired groups that are not in the state
+	Prune bool
+	// ProtectedGroups are never pruned, path.Match patterns on the group name.
+	// DefaultEntitlementsProtectedGroups when nil.
+	ProtectedGroups []string
+	// ProtectedMembers are never pruned and keep their role, i.e the caller of the reconciliation
+	ProtectedMembers []string
+}
+
+// EntitlementsAction is a change of the plan. Role is the new role, FromRole the live one.
+type EntitlementsAction struct {
+	Kind        string `json:"kind"`
+	Group       string `json:"group"`
+	Description string `json:"description,omitempty"`
+	Member      string `json:"member,omitempty"`
+	Role        string `json:"role,omitempty"`
+	FromRole    string `json:"fromRole,omitempty"`
+}
+
+// EntitlementsPlan lists the actions reconciling the live groups with the state, in the
+// order they are applied
+type EntitlementsPlan struct {
+	PartitionId string               `json:"partitionId"`
+	Actions     []EntitlementsAction `json:"actions"`
+	// Protected lists the members kept in a protected group or as protected members while pruning,
+	// and the role changes of the protected members
+	Protected []EntitlementsAction `json:"protected,omitempty"`
+}
+
+// ParseEntitlementsState decodes and validates a YAML or JSON state
+func ParseEntitlementsState(data []byte) (EntitlementsState, error) {
+	var state EntitlementsState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return EntitlementsState{}, err
+	}
+	if err := state.Validate(); err != nil {
+		return EntitlementsState{}, err
+	}
+	return state, nil
+}
+
+// LoadEntitlementsState reads a state file
+func LoadEntitlementsState(path string) (EntitlementsState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return EntitlementsState{}, err
+	}
+	state, err := ParseEntitlementsState(data)
+	if err != nil {
+		return EntitlementsState{}, fmt.Errorf("entitlements state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Validate reports every empty or duplicated group and member and every unknown role
+func (s EntitlementsState) Validate() error {
+	var errs []error
+	groups := map[string]bool{}
+	for i, group := range s.Groups {
+		name := strings.ToLower(group.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("groups[%d]: name cannot be empty", i))
+		case strings.Contains(name, "@"):
+			errs = append(errs, fmt.Errorf("groups[%d]: name %s must not include the partition domain", i, group.Name))
+		case groups[name]:
+			errs = append(errs, fmt.Errorf("groups[%d]: duplicated group %s", i, group.Name))
+		}
+		groups[name] = true
+
+		members := map[string]bool{}
+		for j, member := range group.Members {
+			email := strings.ToLower(member.Email)
+			switch {
+			case email == "":
+				errs = append(errs, fmt.Errorf("groups[%d].members[%d]: email cannot be empty", i, j))
+			case members[email]:
+				errs = append(errs, fmt.Errorf("groups[%d].members[%d]: duplicated member %s", i, j, member.Email))
+			}
+			members[email] = true
+
+			if role := member.role(); role != EntitlementsRoleOwner && role != EntitlementsRoleMember {
+				errs = append(errs, fmt.Errorf("groups[%d].members[%d]: unknown role %q", i, j, member.Role))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m EntitlementsStateMember) role() string {
+	if m.Role == "" {
+		return EntitlementsRoleMember
+	}
+	return strings.ToUpper(m.Role)
+}
+
+// IsProtectedGroup reports whether group matches one of the patterns
+func IsProtectedGroup(group string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(group)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanEntitlements compares the state with the live groups, matched by name. Groups missing
+// from the state are never deleted. Emails and names are compared case insensitively, as the
+// entitlements service lowercases them.
+func PlanEntitlements(state EntitlementsState, live []EntitlementsLiveGroup, options EntitlementsPlanOptions) EntitlementsPlan {
+	protected_groups := options.ProtectedGroups
+	if protected_groups == nil {
+		protected_groups = DefaultEntitlementsProtectedGroups
+	}
+	protected_members := map[string]bool{}
+	for _, member := range options.ProtectedMembers {
+		protected_members[strings.ToLower(member)] = true
+	}
+
+	live_groups := map[string]EntitlementsLiveGroup{}
+	for _, group := range live {
+		live_groups[strings.ToLower(group.Name)] = group
+	}
+
+	plan := EntitlementsPlan{PartitionId: state.PartitionId, Actions: []EntitlementsAction{}}
+	for _, group := range state.Groups {
+		name := strings.ToLower(group.Name)
+		have := live_groups[name]
+
+		if !have.Exists {
+			plan.Actions = append(plan.Actions, EntitlementsAction{
+				Kind: EntitlementsActionCreateGroup, Group: name, Description: group.Description})
+		} else if group.Description != "" && have.Description != nil && *have.Description != group.Description {
+			plan.Actions = append(plan.Actions, EntitlementsAction{
+				Kind: EntitlementsActionUpdateGroup, Group: name, Description: group.Description})
+		}
+
+		live_members := map[string]string{}
+		for _, member := range have.Members {
+			live_members[strings.ToLower(member.Email)] = strings.ToUpper(member.Role)
+		}
+
+		desired := map[string]bool{}
+		for _, member := range group.Members {
+			email := strings.ToLower(member.Email)
+			desired[email] = true
+			role, ok := live_members[email]
+			switch {
+			case !ok:
+				plan.Actions = append(plan.Actions, EntitlementsAction{
+					Kind: EntitlementsActionAddMember, Group: name, Member: email, Role: member.role()})
+			case role != member.role():
+				action := EntitlementsAction{Kind: EntitlementsActionChangeRole, Group: name, Member: email, Role: member.role(), FromRole: role}
+				// A role change removes the member before adding it back, the caller would lose the group
+				if protected_members[email] {
+					plan.Protected = append(plan.Protected, action)
+					continue
+				}
+				plan.Actions = append(plan.Actions, action)
+			}
+		}
+
+		if !options.Prune {
+			continue
+		}
+		emails := make([]string, 0, len(live_members))
+		for email := range live_members {
+			emails = append(emails, email)
+		}
+		sort.Strings(emails)
+		for _, email := range emails {
+			if desired[email] {
+				continue
+			}
+			action := EntitlementsAction{Kind: EntitlementsActionRemoveMember, Group: name, Member: email, FromRole: live_members[email]}
+			if protected_members[email] || IsProtectedGroup(name, protected_groups) {
+				plan.Protected = append(plan.Protected, action)
+				continue
+			}
+			plan.Actions = append(plan.Actions, action)
+		}
+	}
+
+	sort.SliceStable(plan.Actions, func(i, j int) bool {
+		return entitlementsActionOrder(plan.Actions[i].Kind) < entitlementsActionOrder(plan.Actions[j].Kind)
+	})
+	return plan
+}
+
+func entitlementsActionOrder(kind string) int {
+	switch kind {
+	case EntitlementsActionCreateGroup:
+		return 0
+	case EntitlementsActionUpdateGroup:
+		return 1
+	case EntitlementsActionAddMember:
+		return 2
+	case EntitlementsActionChangeRole:
+		return 3
+	}
+	return 4
+}
+
+// HasChanges reports whether the plan has actions
+func (p EntitlementsPlan) HasChanges() bool {
+	return len(p.Actions) > 0
+}
+
+// Count returns the number of actions of kind
+func (p EntitlementsPlan) Count(kind string) int {
+	count := 0
+	for _, action := range p.Actions {
+		if action.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// String returns the plan as text
+//
+//	entitlements opendes: 1 groups to create, 0 to update, 2 members to add, 1 role changes, 1 members to remove
+//	+ group data.tenant.viewers
+//	+ member admin@opendes.com to data.tenant.viewers as OWNER
+//	~ member ops@opendes.com in users.datalake.ops: MEMBER -> OWNER
+//	- member former@opendes.com from data.default.viewers
+//	= member datafier@service.local kept in users (protected)
+//	= member datafier@service.local kept in users.datalake.ops as OWNER (protected)
+func (p EntitlementsPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "entitlements %s: %d groups to create, %d to update, %d members to add, %d role changes, %d members to remove\n",
+		p.PartitionId, p.Count(EntitlementsActionCreateGroup), p.Count(EntitlementsActionUpdateGroup),
+		p.Count(EntitlementsActionAddMember), p.Count(EntitlementsActionChangeRole), p.Count(EntitlementsActionRemoveMember))
+
+	for _, action := range p.Actions {
+		b.WriteString(action.String())
+		b.WriteString("\n")
+	}
+	for _, action := range p.Protected {
+		if action.Kind == EntitlementsActionChangeRole {
+			fmt.Fprintf(&b, "= member %s kept in %s as %s (protected)\n", action.Member, action.Group, action.FromRole)
+			continue
+		}
+		fmt.Fprintf(&b, "= member %s kept in %s (protected)\n", action.Member, action.Group)
+	}
+	return b.String()
+}
+
+func (a EntitlementsAction) String() string {
+	switch a.Kind {
+	case EntitlementsActionCreateGroup:
+		return fmt.Sprintf("+ group %s", a.Group)
+	case EntitlementsActionUpdateGroup:
+		return fmt.Sprintf("~ group %s: description %q", a.Group, a.Description)
+	case EntitlementsActionAddMember:
+		return fmt.Sprintf("+ member %s to %s as %s", a.Member, a.Group, a.Role)
+	case EntitlementsActionChangeRole:
+		return fmt.Sprintf("~ member %s in %s: %s -> %s", a.Member, a.Group, a.FromRole, a.Role)
+	case EntitlementsActionRemoveMember:
+		return fmt.Sprintf("- member %s from %s", a.Member, a.Group)
+	}
+	return fmt.Sprintf("? %s %s", a.Kind, a.Group)
+}
@@ -113,6 +113,9 @@ type PartitionProperties struct {
 	EventgridSchemachangedtopic               PartitionProperty `json:"eventgrid-schemachangedtopic,omitempty"`
 	EventgridSchemachangedtopicAccesskey      PartitionProperty `json:"eventgrid-schemachangedtopic-accesskey,omitempty"`
 	IndexerDecimationEnabled                  PartitionProperty `json:"indexer-decimation-enabled,omitempty"`
+
+	// Extra holds the properties unknown to this struct so they survive a read and re-write
+	Extra PartitionPropertyMap `json:"-"`
 }
 
 func GetDefaultPartitionPropertiesCI(partition_id string) PartitionProperties {
@@ -218,10 +221,10 @@ func GetDefaultPartitionPropertiesCI(partition_id string) PartitionProperties {
 	root.ElasticsearchPassword.Sensitive = true
 
 	root.ElasticsearchHttps.Value = "false"
-	root.ElasticsearchPassword.Sensitive = false
+	root.ElasticsearchHttps.Sensitive = false
 
 	root.ElasticsearchTls.Value = "false"
-	root.ElasticsearchPassword.Sensitive = false
+	root.ElasticsearchTls.Sensitive = false
 
 	root.IndexAugmenterEnabled.Value = "false"
 	root.IndexAugmenterEnabled.Sensitive = false
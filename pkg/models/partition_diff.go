@@ -0,0 +1,172 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Kinds of PartitionPropertyChange
+const (
+	PartitionPropertyAdded       = "added"
+	PartitionPropertyChanged     = "changed"
+	PartitionPropertyRemoved     = "removed"
+	PartitionPropertySensitivity = "sensitivity"
+)
+
+// redactedPartitionValue replaces sensitive values in reports
+const redactedPartitionValue = "******"
+
+// PartitionPropertyState is one side of a change, Value is redacted for a sensitive property.
+// Reports are meant for CD logs, so nothing derived from a sensitive value is kept: even a hash
+// gives away a guessable secret reference.
+type PartitionPropertyState struct {
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// PartitionPropertyChange is the drift of a single property, Live is nil for an added
+// property and Desired is nil for a removed one
+type PartitionPropertyChange struct {
+	Key     string                  `json:"key"`
+	Kind    string                  `json:"kind"`
+	Live    *PartitionPropertyState `json:"live,omitempty"`
+	Desired *PartitionPropertyState `json:"desired,omitempty"`
+}
+
+// SensitivityFlipped reports whether the sensitive flag differs between live and desired
+func (c PartitionPropertyChange) SensitivityFlipped() bool {
+	return c.Live != nil && c.Desired != nil && c.Live.Sensitive != c.Desired.Sensitive
+}
+
+// PartitionDiff lists what registering the desired partition would change on the live one
+type PartitionDiff struct {
+	PartitionId string `json:"partitionId"`
+	// Exists is false when the partition is not registered yet
+	Exists  bool                      `json:"exists"`
+	Changes []PartitionPropertyChange `json:"changes"`
+}
+
+// newPartitionPropertyState redacts the value of a sensitive property
+func newPartitionPropertyState(property PartitionProperty) *PartitionPropertyState {
+	if !property.Sensitive {
+		return &PartitionPropertyState{Value: property.Value}
+	}
+	return &PartitionPropertyState{Value: redactedPartitionValue, Sensitive: true}
+}
+
+// DiffPartition compares the properties of desired with the live partition, Extra included.
+// Properties without value nor sensitive flag are not part of desired. The live partition is
+// taken as registered, set Exists to false for a partition that does not exist yet.
+func DiffPartition(desired, live Partition) PartitionDiff {
+	want := desired.Properties.ToMap()
+	have := live.Properties.ToMap()
+
+	diff := PartitionDiff{
+		PartitionId: desired.Properties.DataPartitionId.Value,
+		Exists:      true,
+		Changes:     []PartitionPropertyChange{},
+	}
+
+	keys := want.Clone()
+	for key, property := range have {
+		keys[key] = property
+	}
+	for _, key := range keys.Keys() {
+		w, inWant := want[key]
+		h, inHave := have[key]
+
+		switch {
+		case inWant && !inHave:
+			diff.Changes = append(diff.Changes, PartitionPropertyChange{
+				Key: key, Kind: PartitionPropertyAdded, Desired: newPartitionPropertyState(w)})
+		case !inWant && inHave:
+			diff.Changes = append(diff.Changes, PartitionPropertyChange{
+				Key: key, Kind: PartitionPropertyRemoved, Live: newPartitionPropertyState(h)})
+		default:
+			// Values are compared here, the report only tells whether they differ
+			sameValue := w.Value == h.Value
+			if sameValue && w.Sensitive == h.Sensitive {
+				continue
+			}
+			kind := PartitionPropertyChanged
+			if sameValue {
+				kind = PartitionPropertySensitivity
+			}
+			diff.Changes = append(diff.Changes, PartitionPropertyChange{
+				Key: key, Kind: kind, Live: newPartitionPropertyState(h), Desired: newPartitionPropertyState(w)})
+		}
+	}
+	return diff
+}
+
+// HasChanges reports whether the live partition differs from the desired one
+func (d PartitionDiff) HasChanges() bool {
+	return !d.Exists || len(d.Changes) > 0
+}
+
+// Count returns the number of changes of kind
+func (d PartitionDiff) Count(kind string) int {
+	count := 0
+	for _, change := range d.Changes {
+		if change.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// JSON returns the report as indented JSON
+func (d PartitionDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// String returns the report as text, sensitive values are never printed
+//
+//	partition opendes (registered): 1 added, 1 changed, 1 removed, 1 sensitivity
+//	+ obm.minio.endpoint = "http://minio:9000"
+//	~ bucket: "refi-opendes-records" -> "osdu-opendes-records"
+//	- legacy.property (was "true")
+//	! osm.postgres.datasource.password: sensitive -> not sensitive
+func (d PartitionDiff) String() string {
+	var b strings.Builder
+	state := "registered"
+	if !d.Exists {
+		state = "not registered"
+	}
+	fmt.Fprintf(&b, "partition %s (%s): %d added, %d changed, %d removed, %d sensitivity\n", d.PartitionId, state,
+		d.Count(PartitionPropertyAdded), d.Count(PartitionPropertyChanged),
+		d.Count(PartitionPropertyRemoved), d.Count(PartitionPropertySensitivity))
+
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case PartitionPropertyAdded:
+			fmt.Fprintf(&b, "+ %s = %s\n", c.Key, c.Desired.display())
+		case PartitionPropertyRemoved:
+			fmt.Fprintf(&b, "- %s (was %s)\n", c.Key, c.Live.display())
+		case PartitionPropertyChanged:
+			fmt.Fprintf(&b, "~ %s: %s -> %s", c.Key, c.Live.display(), c.Desired.display())
+			if c.SensitivityFlipped() {
+				fmt.Fprintf(&b, " (%s -> %s)", c.Live.sensitivity(), c.Desired.sensitivity())
+			}
+			b.WriteString("\n")
+		case PartitionPropertySensitivity:
+			fmt.Fprintf(&b, "! %s: %s -> %s\n", c.Key, c.Live.sensitivity(), c.Desired.sensitivity())
+		}
+	}
+	return b.String()
+}
+
+func (s PartitionPropertyState) display() string {
+	if s.Sensitive {
+		return redactedPartitionValue
+	}
+	return fmt.Sprintf("%q", s.Value)
+}
+
+func (s PartitionPropertyState) sensitivity() string {
+	if s.Sensitive {
+		return "sensitive"
+	}
+	return "not sensitive"
+}
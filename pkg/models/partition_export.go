@@ -0,0 +1,203 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// PartitionExport is a partition definition as kept in git. Sensitive values are the references
+// stored by the partition service (secret names), they are never resolved.
+type PartitionExport struct {
+	PartitionId string               `yaml:"partitionId" json:"partitionId"`
+	Properties  PartitionPropertyMap `yaml:"properties" json:"properties"`
+}
+
+// PartitionExportFile is the content of an export file (YAML or JSON)
+//
+//	partitions:
+//	  - partitionId: opendes
+//	    properties:
+//	      bucket:
+//	        sensitive: false
+//	        value: ${bucketPrefix}-opendes-records
+type PartitionExportFile struct {
+	Partitions []PartitionExport `yaml:"partitions" json:"partitions"`
+}
+
+// PartitionImportOptions adapts exported partitions to the target environment
+type PartitionImportOptions struct {
+	// PartitionIds remaps the exported partition ids, i.e opendes: tenant-a. The dataPartitionId
+	// and name properties follow the new id.
+	PartitionIds map[string]string
+	// RenameInValues also replaces the old id in the values (lower and upper case) where it is
+	// delimited by -, _, ., /, @ or the ends of the value, i.e refi-opendes-records and
+	// POSTGRES_DB_PASSWORD_OPENDES. Use ${partitionId} for values the id is not a token of.
+	RenameInValues bool
+	// Values substitutes ${key} in the values, ${partitionId} and ${PARTITION_ID} are always
+	// set to the target partition id
+	Values map[string]string
+}
+
+// partitionTokenDelimiters separate the partition id from the rest of a value
+const partitionTokenDelimiters = "-_./@"
+
+// partitionValueVariable matches ${key} in property values
+var partitionValueVariable = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// NewPartitionExport returns the export of partition
+func NewPartitionExport(partition Partition) PartitionExport {
+	return PartitionExport{
+		PartitionId: partition.Properties.DataPartitionId.Value,
+		Properties:  partition.Properties.ToMap(),
+	}
+}
+
+// MarshalPartitionExport encodes exports as JSON when format is json, YAML otherwise
+func MarshalPartitionExport(exports []PartitionExport, format string) ([]byte, error) {
+	file := PartitionExportFile{Partitions: exports}
+	if format == "json" {
+		return json.MarshalIndent(file, "", "  ")
+	}
+	return yaml.Marshal(file)
+}
+
+// ParsePartitionExport decodes a YAML or JSON export file
+func ParsePartitionExport(data []byte) ([]PartitionExport, error) {
+	var file PartitionExportFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for i, export := range file.Partitions {
+		if len(export.PartitionId) < 2 {
+			return nil, fmt.Errorf("partitions[%d]: partitionId cannot be empty", i)
+		}
+	}
+	return file.Partitions, nil
+}
+
+// WritePartitionExport writes exports to path, as JSON for a .json file and YAML otherwise
+func WritePartitionExport(path string, exports []PartitionExport) error {
+	format := "yaml"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = "json"
+	}
+	data, err := MarshalPartitionExport(exports, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadPartitionExport reads an export file
+func LoadPartitionExport(path string) ([]PartitionExport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	exports, err := ParsePartitionExport(data)
+	if err != nil {
+		return nil, fmt.Errorf("partition export %s: %w", path, err)
+	}
+	return exports, nil
+}
+
+// LoadPartitionValues reads a values file, a flat YAML or JSON map of strings
+func LoadPartitionValues(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("partition values %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Partition returns the partition to register in the target environment, with its id remapped
+// and the ${key} variables substituted. Every undefined variable is reported.
+func (e PartitionExport) Partition(options PartitionImportOptions) (Partition, error) {
+	old_id := e.PartitionId
+	partition_id := old_id
+	if id, ok := options.PartitionIds[old_id]; ok && id != "" {
+		partition_id = id
+	}
+
+	values := map[string]string{}
+	for key, value := range options.Values {
+		values[key] = value
+	}
+	values["partitionId"] = partition_id
+	values["PARTITION_ID"] = strings.ToUpper(partition_id)
+
+	properties := PartitionPropertyMap{}
+	undefined := map[string]bool{}
+	for key, property := range e.Properties {
+		if options.RenameInValues && partition_id != old_id {
+			property.Value = replacePartitionToken(property.Value, old_id, partition_id)
+			property.Value = replacePartitionToken(property.Value, strings.ToUpper(old_id), strings.ToUpper(partition_id))
+		}
+		property.Value = partitionValueVariable.ReplaceAllStringFunc(property.Value, func(variable string) string {
+			name := partitionValueVariable.FindStringSubmatch(variable)[1]
+			value, ok := values[name]
+			if !ok {
+				undefined[name] = true
+				return variable
+			}
+			return value
+		})
+		properties[key] = property
+	}
+
+	if len(undefined) > 0 {
+		var errs []error
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			errs = append(errs, fmt.Errorf("partition %s: undefined value ${%s}", old_id, name))
+		}
+		return Partition{}, errors.Join(errs...)
+	}
+
+	properties[PropertyDataPartitionId] = PartitionProperty{Value: partition_id}
+	if name, ok := properties[PropertyName]; !ok || name.Value == old_id {
+		properties[PropertyName] = PartitionProperty{Value: partition_id}
+	}
+	return Partition{Properties: properties.ToProperties()}, nil
+}
+
+// replacePartitionToken replaces from with to in value where from is a whole token, so an id
+// like dp1 leaves dp10-records and adp1 untouched
+func replacePartitionToken(value string, from string, to string) string {
+	var replaced strings.Builder
+	for {
+		i := strings.Index(value, from)
+		if i < 0 {
+			replaced.WriteString(value)
+			return replaced.String()
+		}
+		end := i + len(from)
+		before := i == 0 || strings.IndexByte(partitionTokenDelimiters, value[i-1]) >= 0
+		after := end == len(value) || strings.IndexByte(partitionTokenDelimiters, value[end]) >= 0
+		replaced.WriteString(value[:i])
+		if before && after {
+			replaced.WriteString(to)
+			value = value[end:]
+		} else {
+			// Not a token, from may still start inside the match
+			replaced.WriteString(value[i : i+1])
+			value = value[i+1:]
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Names of the properties known by PartitionProperties
+const (
+	// Known properties for CI implementation
+	PropertyProjectId                     = "projectId"
+	PropertyServiceAccount                = "serviceAccount"
+	PropertyComplianceRuleSet             = "complianceRuleSet"
+	PropertyDataPartitionId               = "dataPartitionId"
+	PropertyName                          = "name"
+	PropertyBucket                        = "bucket"
+	PropertyCrmAccountID                  = "crmAccountID"
+	PropertyOsmPostgresDatasourceUrl      = "osm.postgres.datasource.url"
+	PropertyOsmPostgresDatasourceUsername = "osm.postgres.datasource.username"
+	PropertyOsmPostgresDatasourcePassword = "osm.postgres.datasource.password"
+	PropertyObmMinioEndpoint              = "obm.minio.endpoint"
+	PropertyObmMinioAccessKey             = "obm.minio.accessKey"
+	PropertyObmMinioSecretKey             = "obm.minio.secretKey"
+	PropertyObmMinioIgnoreCertCheck       = "obm.minio.ignoreCertCheck"
+	PropertyObmMinioUiEndpoint            = "obm.minio.ui.endpoint"
+	PropertyKubernetesSecretName          = "kubernetes-secret-name"
+	PropertyOqmRabbitmqAmqpHost           = "oqm.rabbitmq.amqp.host"
+	PropertyOqmRabbitmqAmqpPort           = "oqm.rabbitmq.amqp.port"
+	PropertyOqmRabbitmqAmqpPath           = "oqm.rabbitmq.amqp.path"
+	PropertyOqmRabbitmqAmqpUsername       = "oqm.rabbitmq.amqp.username"
+	PropertyOqmRabbitmqAmqpPassword       = "oqm.rabbitmq.amqp.password"
+	PropertyOqmRabbitmqAdminSchema        = "oqm.rabbitmq.admin.schema"
+	PropertyOqmRabbitmqAdminHost          = "oqm.rabbitmq.admin.host"
+	PropertyOqmRabbitmqAdminPort          = "oqm.rabbitmq.admin.port"
+	PropertyOqmRabbitmqAdminPath          = "oqm.rabbitmq.admin.path"
+	PropertyOqmRabbitmqAdminUsername      = "oqm.rabbitmq.admin.username"
+	PropertyOqmRabbitmqAdminPassword      = "oqm.rabbitmq.admin.password"
+	PropertyElasticsearchHost             = "elasticsearch.8.host"
+	PropertyElasticsearchPort             = "elasticsearch.8.port"
+	PropertyElasticsearchUser             = "elasticsearch.8.user"
+	PropertyElasticsearchPassword         = "elasticsearch.8.password"
+	PropertyElasticsearchHttps            = "elasticsearch.8.https"
+	PropertyElasticsearchTls              = "elasticsearch.8.tls"
+	PropertyElasticsearchSevenHost        = "elasticsearch.host"
+	PropertyElasticsearchSevenPort        = "elasticsearch.port"
+	PropertyElasticsearchSevenUser        = "elasticsearch.user"
+	PropertyElasticsearchSevenPassword    = "elasticsearch.password"
+	PropertyElasticsearchSevenHttps       = "elasticsearch.https"
+	PropertyElasticsearchSevenTls         = "elasticsearch.tls"
+	PropertyIndexAugmenterEnabled         = "index-augmenter-enabled"
+	PropertyFeatureFlagPolicyEnabled      = "featureFlag.policy.enabled"
+	PropertyFeatureFlagOpaEnabled         = "featureFlag.opa.enabled"
+	PropertyObmMinioExternalEndpoint      = "obm.minio.external.endpoint"
+	PropertyWellboreDmsBucket             = "wellbore-dms-bucket"
+	PropertyReservoirConnection           = "reservoir-connection"
+	PropertyLegalBucketName               = "legal.bucket.name"
+	PropertyStorageBucketName             = "storage.bucket.name"
+	PropertySchemaBucketName              = "schema.bucket.name"
+	PropertyFileStagingLocation           = "file.staging.location"
+	PropertyFilePersistenLocation         = "file.persistent.location"
+
+	// Needed by system partition
+	PropertyEntitlementsDatasourceUrl      = "entitlements.datasource.url"
+	PropertyEntitlementsDatasourceUsername = "entitlements.datasource.username"
+	PropertyEntitlementsDatasourcePassword = "entitlements.datasource.password"
+	PropertyEntitlementsDatasourceSchema   = "entitlements.datasource.schema"
+	PropertySystemSchemaBucketName         = "system.schema.bucket.name"
+
+	// Known properties for azure
+	PropertyAzureComplianceRuleset                    = "compliance-ruleset"
+	PropertyElasticSevenEndpoint                      = "elastic-endpoint"
+	PropertyElasticSevenUsername                      = "elastic-username"
+	PropertyElasticSevenPassword                      = "elastic-password"
+	PropertyElasticSevenSslEnabled                    = "elastic-ssl-enabled"
+	PropertyCosmosConnection                          = "cosmos-connection"
+	PropertyCosmosEndpoint                            = "cosmos-endpoint"
+	PropertyCosmosPrimaryKey                          = "cosmos-primary-key"
+	PropertySbConnection                              = "sb-connection"
+	PropertySbNamespace                               = "sb-namespace"
+	PropertyStorageAccountKey                         = "storage-account-key"
+	PropertyStorageAccountName                        = "storage-account-name"
+	PropertyStorageAccountBlobEndpoint                = "storage-account-blob-endpoint"
+	PropertyIngestStorageAccountName                  = "ingest-storage-account-name"
+	PropertyIngestStorageAccountKey                   = "ingest-storage-account-key"
+	PropertyHierarchicalStorageAccountName            = "hierarchical-storage-account-name"
+	PropertyHierarchicalStorageAccountKey             = "hierarchical-storage-account-key"
+	PropertyEventgridRecordstopic                     = "eventgrid-recordstopic"
+	PropertyEventgridRecordstopicAccesskey            = "eventgrid-recordstopic-accesskey"
+	PropertyEventgridLegaltagschangedtopic            = "eventgrid-legaltagschangedtopic"
+	PropertyEventgridLegaltagschangedtopicAccesskey   = "eventgrid-legaltagschangedtopic-accesskey"
+	PropertyEventgridResourcegroup                    = "eventgrid-resourcegroup"
+	PropertyEncryptionKeyIdentifier                   = "encryption-key-identifier"
+	PropertySdmsStorageAccountName                    = "sdms-storage-account-name"
+	PropertySdmsStorageAccountKey                     = "sdms-storage-account-key"
+	PropertyEventgridSchemanotificationtopic          = "eventgrid-schemanotificationtopic"
+	PropertyEventgridSchemanotificationtopicAccesskey = "eventgrid-schemanotificationtopic-accesskey"
+	PropertyEventgridGsmtopic                         = "eventgrid-gsmtopic"
+	PropertyEventgridGsmtopicAccesskey                = "eventgrid-gsmtopic-accesskey"
+	PropertyEventgridStatuschangedtopic               = "eventgrid-statuschangedtopic"
+	PropertyEventgridStatuschangedtopicAccesskey      = "eventgrid-statuschangedtopic-accesskey"
+	PropertyEventgridSchemachangedtopic               = "eventgrid-schemachangedtopic"
+	PropertyEventgridSchemachangedtopicAccesskey      = "eventgrid-schemachangedtopic-accesskey"
+	PropertyIndexerDecimationEnabled                  = "indexer-decimation-enabled"
+)
+
+// PartitionPropertyMap holds partition properties keyed by name, including the ones
+// PartitionProperties does not know
+type PartitionPropertyMap map[string]PartitionProperty
+
+// IsKnownPartitionProperty reports whether key is a field of PartitionProperties
+func IsKnownPartitionProperty(key string) bool {
+	_, known := partitionPropertyFields[key]
+	return known
+}
+
+// Get returns the property key and whether it is set
+func (m PartitionPropertyMap) Get(key string) (PartitionProperty, bool) {
+	property, ok := m[key]
+	return property, ok
+}
+
+// Value returns the value of the property key, empty when not set
+func (m PartitionPropertyMap) Value(key string) string {
+	return m[key].Value
+}
+
+// Sensitive reports whether the property key is flagged sensitive
+func (m PartitionPropertyMap) Sensitive(key string) bool {
+	return m[key].Sensitive
+}
+
+// Set sets the value and sensitivity of the property key
+func (m PartitionPropertyMap) Set(key, value string, sensitive bool) {
+	m[key] = PartitionProperty{Value: value, Sensitive: sensitive}
+}
+
+// Delete removes the property key
+func (m PartitionPropertyMap) Delete(key string) {
+	delete(m, key)
+}
+
+// Keys returns the property names, sorted
+func (m PartitionPropertyMap) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unknown returns the names of the properties PartitionProperties does not know, sorted
+func (m PartitionPropertyMap) Unknown() []string {
+	var keys []string
+	for _, key := range m.Keys() {
+		if !IsKnownPartitionProperty(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Clone returns a copy of the map
+func (m PartitionPropertyMap) Clone() PartitionPropertyMap {
+	clone := make(PartitionPropertyMap, len(m))
+	for key, property := range m {
+		clone[key] = property
+	}
+	return clone
+}
+
+// ToProperties returns the known properties as fields and the other ones in Extra
+func (m PartitionPropertyMap) ToProperties() PartitionProperties {
+	var properties PartitionProperties
+	root := reflect.ValueOf(&properties).Elem()
+	for key, property := range m {
+		if index, known := partitionPropertyFields[key]; known {
+			root.Field(index).Set(reflect.ValueOf(property))
+			continue
+		}
+		if properties.Extra == nil {
+			properties.Extra = PartitionPropertyMap{}
+		}
+		properties.Extra[key] = property
+	}
+	return properties
+}
+
+// ToMap returns the properties with a value or flagged sensitive, keyed by name, Extra included
+func (p PartitionProperties) ToMap() PartitionPropertyMap {
+	m := PartitionPropertyMap{}
+	root := reflect.ValueOf(p)
+	for key, index := range partitionPropertyFields {
+		property := root.Field(index).Interface().(PartitionProperty)
+		if property != (PartitionProperty{}) {
+			m[key] = property
+		}
+	}
+	for key, property := range p.Extra {
+		if _, known := partitionPropertyFields[key]; !known {
+			m[key] = property
+		}
+	}
+	return m
+}
+
+// MarshalJSON writes the known properties followed by the Extra ones
+func (p PartitionProperties) MarshalJSON() ([]byte, error) {
+	type plain PartitionProperties
+	data, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extra) == 0 {
+		return data, err
+	}
+
+	var m map[string]PartitionProperty
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for key, property := range p.Extra {
+		if _, known := partitionPropertyFields[key]; !known {
+			m[key] = property
+		}
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON reads the known properties in their fields and keeps the other ones in Extra
+func (p *PartitionProperties) UnmarshalJSON(data []byte) error {
+	type plain PartitionProperties
+	var known plain
+	if err := json.Unmarshal(data, &known); err != nil {
+		return err
+	}
+
+	var m map[string]PartitionProperty
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	known.Extra = nil
+	for key, property := range m {
+		if _, ok := partitionPropertyFields[key]; ok {
+			continue
+		}
+		if known.Extra == nil {
+			known.Extra = PartitionPropertyMap{}
+		}
+		known.Extra[key] = property
+	}
+
+	*p = PartitionProperties(known)
+	return nil
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// PartitionPropertyOverride changes a single property of a partition template.
+// Value and Sensitive are optional, the template ones are kept when omitted.
+type PartitionPropertyOverride struct {
+	Value     *string `yaml:"value" json:"value,omitempty"`
+	Sensitive *bool   `yaml:"sensitive" json:"sensitive,omitempty"`
+	// Remove clears the property of the template (empty value, not sensitive)
+	Remove bool `yaml:"remove" json:"remove,omitempty"`
+}
+
+// PartitionOverrides is the content of an OsduSettings.PartitionOverrides file (YAML or JSON)
+//
+//	properties:
+//	  obm.minio.endpoint:
+//	    value: http://minio:9000
+//	  elasticsearch.8.password:
+//	    value: ELASTIC_PASS_OPENDES
+//	    sensitive: true
+//	  obm.minio.external.endpoint:
+//	    remove: true
+//	  bucket: refi-opendes-records   # shorthand for value only
+type PartitionOverrides struct {
+	Properties map[string]PartitionPropertyOverride `yaml:"properties" json:"properties"`
+}
+
+// UnmarshalYAML accepts a plain scalar as a value-only override and rejects unknown fields
+func (o *PartitionPropertyOverride) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		value := node.Value
+		*o = PartitionPropertyOverride{Value: &value}
+		return nil
+	}
+
+	type plain PartitionPropertyOverride
+	var p plain
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*o = PartitionPropertyOverride(p)
+	return nil
+}
+
+// partitionPropertyFields maps the JSON name of each known property to its field index
+var partitionPropertyFields = func() map[string]int {
+	fields := map[string]int{}
+	t := reflect.TypeOf(PartitionProperties{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type != reflect.TypeOf(PartitionProperty{}) {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// PartitionPropertyKeys returns the JSON names of the known partition properties, sorted
+func PartitionPropertyKeys() []string {
+	keys := make([]string, 0, len(partitionPropertyFields))
+	for key := range partitionPropertyFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParsePartitionOverrides decodes and validates YAML or JSON overrides
+func ParsePartitionOverrides(data []byte) (PartitionOverrides, error) {
+	var overrides PartitionOverrides
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&overrides); err != nil {
+		return PartitionOverrides{}, err
+	}
+	if err := overrides.Validate(); err != nil {
+		return PartitionOverrides{}, err
+	}
+	return overrides, nil
+}
+
+// LoadPartitionOverrides reads and validates an overrides file
+func LoadPartitionOverrides(path string) (PartitionOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PartitionOverrides{}, err
+	}
+	overrides, err := ParsePartitionOverrides(data)
+	if err != nil {
+		return PartitionOverrides{}, fmt.Errorf("partition overrides %s: %w", path, err)
+	}
+	return overrides, nil
+}
+
+// Validate rejects unknown properties and contradictory overrides
+func (o PartitionOverrides) Validate() error {
+	keys := make([]string, 0, len(o.Properties))
+	for key := range o.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		override := o.Properties[key]
+		_, known := partitionPropertyFields[key]
+		switch {
+		case !known:
+			errs = append(errs, fmt.Errorf("unknown partition property %q", key))
+		case override.Remove && (override.Value != nil || override.Sensitive != nil):
+			errs = append(errs, fmt.Errorf("property %q: remove cannot be combined with value or sensitive", key))
+		case !override.Remove && override.Value == nil && override.Sensitive == nil:
+			errs = append(errs, fmt.Errorf("property %q: override sets neither value, sensitive nor remove", key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Apply returns properties with the overrides merged on top
+func (o PartitionOverrides) Apply(properties PartitionProperties) (PartitionProperties, error) {
+	if err := o.Validate(); err != nil {
+		return properties, err
+	}
+
+	root := reflect.ValueOf(&properties).Elem()
+	for key, override := range o.Properties {
+		property := root.Field(partitionPropertyFields[key]).Addr().Interface().(*PartitionProperty)
+		if override.Remove {
+			*property = PartitionProperty{}
+			continue
+		}
+		if override.Value != nil {
+			property.Value = *override.Value
+		}
+		if override.Sensitive != nil {
+			property.Sensitive = *override.Sensitive
+		}
+	}
+	return properties, nil
+}
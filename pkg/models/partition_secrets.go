@@ -0,0 +1,303 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultPartitionSecretName is the secret holding the sensitive partition values when
+// KubernetesSecretName is not set
+const DefaultPartitionSecretName = "eds-osdu"
+
+// kubernetesSecretKey is the format of the keys of a Kubernetes Secret
+var kubernetesSecretKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// PartitionSecretOptions customizes the generated manifests
+type PartitionSecretOptions struct {
+	// Namespace of the manifests, omitted when empty
+	Namespace string
+	// SecretName replaces the KubernetesSecretName property
+	SecretName string
+	// SecretStore adds an ExternalSecret reading every key from this store
+	SecretStore string
+	// SecretStoreKind is SecretStore or ClusterSecretStore (default)
+	SecretStoreKind string
+}
+
+// KubernetesSecret is the manifest of a Kubernetes Secret
+type KubernetesSecret struct {
+	ApiVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   KubernetesMetadata `yaml:"metadata"`
+	Type       string             `yaml:"type,omitempty"`
+	Data       map[string]string  `yaml:"data,omitempty"`
+	StringData map[string]string  `yaml:"stringData,omitempty"`
+}
+
+// KubernetesMetadata is the metadata of a manifest
+type KubernetesMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// ExternalSecret is the manifest of an external-secrets.io ExternalSecret
+type ExternalSecret struct {
+	ApiVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   KubernetesMetadata `yaml:"metadata"`
+	Spec       ExternalSecretSpec `yaml:"spec"`
+}
+
+type ExternalSecretSpec struct {
+	RefreshInterval string                 `yaml:"refreshInterval"`
+	SecretStoreRef  ExternalSecretStoreRef `yaml:"secretStoreRef"`
+	Target          ExternalSecretTarget   `yaml:"target"`
+	Data            []ExternalSecretData   `yaml:"data"`
+}
+
+type ExternalSecretStoreRef struct {
+	Name string `yaml:"name"`
+	Kind string `yaml:"kind"`
+}
+
+type ExternalSecretTarget struct {
+	Name           string `yaml:"name"`
+	CreationPolicy string `yaml:"creationPolicy"`
+}
+
+type ExternalSecretData struct {
+	SecretKey string                  `yaml:"secretKey"`
+	RemoteRef ExternalSecretRemoteRef `yaml:"remoteRef"`
+}
+
+type ExternalSecretRemoteRef struct {
+	Key string `yaml:"key"`
+}
+
+// PartitionSecretName returns the secret holding the sensitive values of properties
+func PartitionSecretName(properties PartitionProperties) string {
+	if name := properties.KubernetesSecretName.Value; name != "" {
+		return name
+	}
+	return DefaultPartitionSecretName
+}
+
+// PartitionSecretKeys returns the secret names referenced by the sensitive properties, sorted
+func PartitionSecretKeys(properties PartitionProperties) []string {
+	unique := map[string]bool{}
+	for _, property := range properties.ToMap() {
+		if property.Sensitive && property.Value != "" {
+			unique[property.Value] = true
+		}
+	}
+
+	keys := make([]string, 0, len(unique))
+	for key := range unique {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GeneratePartitionSecrets returns the YAML skeleton of the Secret listing every key referenced
+// by properties with an empty value, followed by an ExternalSecret when options.SecretStore is set
+func GeneratePartitionSecrets(properties PartitionProperties, options PartitionSecretOptions) ([]byte, error) {
+	keys := PartitionSecretKeys(properties)
+	var invalid []string
+	for _, key := range keys {
+		if !kubernetesSecretKey.MatchString(key) {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("not valid Kubernetes secret keys: %s", strings.Join(invalid, ", "))
+	}
+
+	metadata := KubernetesMetadata{Name: options.SecretName, Namespace: options.Namespace}
+	if metadata.Name == "" {
+		metadata.Name = PartitionSecretName(properties)
+	}
+
+	secret := KubernetesSecret{
+		ApiVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   metadata,
+		Type:       "Opaque",
+		StringData: map[string]string{},
+	}
+	for _, key := range keys {
+		secret.StringData[key] = ""
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(secret); err != nil {
+		return nil, err
+	}
+
+	if options.SecretStore != "" {
+		kind := options.SecretStoreKind
+		if kind == "" {
+			kind = "ClusterSecretStore"
+		}
+		external := ExternalSecret{
+			ApiVersion: "external-secrets.io/v1beta1",
+			Kind:       "ExternalSecret",
+			Metadata:   metadata,
+			Spec: ExternalSecretSpec{
+				RefreshInterval: "1h",
+				SecretStoreRef:  ExternalSecretStoreRef{Name: options.SecretStore, Kind: kind},
+				Target:          ExternalSecretTarget{Name: metadata.Name, CreationPolicy: "Owner"},
+				Data:            []ExternalSecretData{},
+			},
+		}
+		for _, key := range keys {
+			external.Spec.Data = append(external.Spec.Data, ExternalSecretData{
+				SecretKey: key,
+				RemoteRef: ExternalSecretRemoteRef{Key: key},
+			})
+		}
+		if err := encoder.Encode(external); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// CheckPartitionSecrets returns the keys referenced by properties missing from data, sorted.
+// data is either a Secret (or ExternalSecret) manifest named after the partition secret, or
+// an env file (KEY=value lines).
+func CheckPartitionSecrets(properties PartitionProperties, data []byte) ([]string, error) {
+	var present map[string]bool
+	var err error
+	if isKubernetesManifest(data) {
+		present, err = manifestSecretKeys(data, PartitionSecretName(properties))
+	} else {
+		present, err = envFileKeys(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, key := range PartitionSecretKeys(properties) {
+		if !present[key] {
+			missing = append(missing, key)
+		}
+	}
+	return missing, nil
+}
+
+// CheckPartitionSecretsFile is CheckPartitionSecrets reading a manifest or env file
+func CheckPartitionSecretsFile(properties PartitionProperties, path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := CheckPartitionSecrets(properties, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return missing, nil
+}
+
+// isKubernetesManifest reports whether the first document of data has a kind
+func isKubernetesManifest(data []byte) bool {
+	var doc struct {
+		Kind string `yaml:"kind"`
+	}
+	return yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc) == nil && doc.Kind != ""
+}
+
+// manifestSecretKeys returns the keys of the Secret and ExternalSecret documents named name
+func manifestSecretKeys(data []byte, name string) (map[string]bool, error) {
+	keys := map[string]bool{}
+	found := false
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc struct {
+			Kind     string             `yaml:"kind"`
+			Metadata KubernetesMetadata `yaml:"metadata"`
+			// Secret
+			Data       map[string]string `yaml:"data"`
+			StringData map[string]string `yaml:"stringData"`
+			// ExternalSecret
+			Spec struct {
+				Target ExternalSecretTarget `yaml:"target"`
+				Data   []ExternalSecretData `yaml:"data"`
+			} `yaml:"spec"`
+		}
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch doc.Kind {
+		case "Secret":
+			if doc.Metadata.Name != name {
+				continue
+			}
+			for key := range doc.Data {
+				keys[key] = true
+			}
+			for key := range doc.StringData {
+				keys[key] = true
+			}
+		case "ExternalSecret":
+			target := doc.Spec.Target.Name
+			if target == "" {
+				target = doc.Metadata.Name
+			}
+			if target != name {
+				continue
+			}
+			for _, d := range doc.Spec.Data {
+				keys[d.SecretKey] = true
+			}
+		default:
+			continue
+		}
+		found = true
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no Secret or ExternalSecret named %s", name)
+	}
+	return keys, nil
+}
+
+// envFileKeys returns the keys of an env file, comments and export prefixes are allowed
+func envFileKeys(data []byte) (map[string]bool, error) {
+	keys := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		keys[strings.TrimSpace(key)] = true
+	}
+	return keys, scanner.Err()
+}
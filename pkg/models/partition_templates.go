@@ -0,0 +1,345 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Partition templates, one per cloud provider
+const (
+	PartitionTemplateCI    = "ci"
+	PartitionTemplateAzure = "azure"
+	PartitionTemplateAWS   = "aws"
+	PartitionTemplateGCP   = "gcp"
+)
+
+// PartitionTemplates lists the supported partition templates
+var PartitionTemplates = []string{PartitionTemplateCI, PartitionTemplateAzure, PartitionTemplateAWS, PartitionTemplateGCP}
+
+// partitionTemplateRequired lists the properties each template must set
+var partitionTemplateRequired = map[string][]string{
+	PartitionTemplateCI: {
+		PropertyDataPartitionId, PropertyName, PropertyBucket,
+		PropertyOsmPostgresDatasourceUrl, PropertyOsmPostgresDatasourceUsername, PropertyOsmPostgresDatasourcePassword,
+		PropertyObmMinioEndpoint, PropertyObmMinioAccessKey, PropertyObmMinioSecretKey,
+		PropertyOqmRabbitmqAmqpHost, PropertyOqmRabbitmqAmqpUsername, PropertyOqmRabbitmqAmqpPassword,
+		PropertyElasticsearchHost, PropertyElasticsearchPort, PropertyElasticsearchUser, PropertyElasticsearchPassword,
+	},
+	PartitionTemplateAzure: {
+		PropertyDataPartitionId, PropertyName, PropertyAzureComplianceRuleset,
+		PropertyCosmosConnection, PropertyCosmosEndpoint, PropertyCosmosPrimaryKey,
+		PropertySbConnection, PropertySbNamespace,
+		PropertyStorageAccountName, PropertyStorageAccountKey,
+		PropertyElasticSevenEndpoint, PropertyElasticSevenUsername, PropertyElasticSevenPassword,
+	},
+	PartitionTemplateAWS: {
+		PropertyDataPartitionId, PropertyName,
+		PropertyLegalBucketName, PropertyStorageBucketName, PropertySchemaBucketName,
+		PropertyFileStagingLocation, PropertyFilePersistenLocation,
+		PropertyElasticsearchHost, PropertyElasticsearchPort, PropertyElasticsearchUser, PropertyElasticsearchPassword,
+	},
+	PartitionTemplateGCP: {
+		PropertyDataPartitionId, PropertyName, PropertyProjectId, PropertyServiceAccount, PropertyBucket,
+		PropertyOsmPostgresDatasourceUrl, PropertyOsmPostgresDatasourceUsername, PropertyOsmPostgresDatasourcePassword,
+		PropertyElasticsearchHost, PropertyElasticsearchPort, PropertyElasticsearchUser, PropertyElasticsearchPassword,
+	},
+}
+
+// PartitionTemplateForProvider returns the template of an osdu.provider configuration value.
+// The azure provider selects the azure template, openid (or empty) the CI one.
+func PartitionTemplateForProvider(provider string) (string, error) {
+	switch provider {
+	case "", "openid":
+		return PartitionTemplateCI, nil
+	case PartitionTemplateAzure, PartitionTemplateAWS, PartitionTemplateGCP:
+		return provider, nil
+	}
+	return "", fmt.Errorf("provider %q has no partition template, select one of %s explicitly",
+		provider, strings.Join(PartitionTemplates, ", "))
+}
+
+// GetDefaultPartitionProperties returns the default properties of template for partition_id
+func GetDefaultPartitionProperties(template string, partition_id string) (PartitionProperties, error) {
+	switch template {
+	case PartitionTemplateCI:
+		return GetDefaultPartitionPropertiesCI(partition_id), nil
+	case PartitionTemplateAzure:
+		return GetDefaultPartitionPropertiesAzure(partition_id), nil
+	case PartitionTemplateAWS:
+		return GetDefaultPartitionPropertiesAWS(partition_id), nil
+	case PartitionTemplateGCP:
+		return GetDefaultPartitionPropertiesGCP(partition_id), nil
+	}
+	return PartitionProperties{}, fmt.Errorf("unknown partition template %q, expected one of %s",
+		template, strings.Join(PartitionTemplates, ", "))
+}
+
+// PartitionTemplateRequired returns the properties template must set
+func PartitionTemplateRequired(template string) []string {
+	return append([]string{}, partitionTemplateRequired[template]...)
+}
+
+// ValidatePartitionTemplate reports every required property of template missing from properties
+func ValidatePartitionTemplate(template string, properties PartitionProperties) error {
+	required, ok := partitionTemplateRequired[template]
+	if !ok {
+		return fmt.Errorf("unknown partition template %q, expected one of %s",
+			template, strings.Join(PartitionTemplates, ", "))
+	}
+
+	set := properties.ToMap()
+	var errs []error
+	for _, key := range required {
+		if set.Value(key) == "" {
+			errs = append(errs, fmt.Errorf("%s partition template requires property %q", template, key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetDefaultPartitionPropertiesAzure follows the Azure convention: sensitive values are the
+// names of Key Vault secrets prefixed by the partition id
+func GetDefaultPartitionPropertiesAzure(partition_id string) PartitionProperties {
+	slog.Info(fmt.Sprintf("Gathering default values for partition Azure %s", partition_id))
+	root := PartitionProperties{}
+	secret := func(name string) string {
+		return fmt.Sprintf("%s-%s", partition_id, name)
+	}
+
+	root.DataPartitionId.Value = partition_id
+	root.DataPartitionId.Sensitive = false
+
+	root.Name.Value = partition_id
+	root.Name.Sensitive = false
+
+	root.AzureComplianceRuleset.Value = "shared"
+	root.AzureComplianceRuleset.Sensitive = false
+
+	root.ElasticSevenEndpoint.Value = secret("elastic-endpoint")
+	root.ElasticSevenEndpoint.Sensitive = true
+
+	root.ElasticSevenUsername.Value = secret("elastic-username")
+	root.ElasticSevenUsername.Sensitive = true
+
+	root.ElasticSevenPassword.Value = secret("elastic-password")
+	root.ElasticSevenPassword.Sensitive = true
+
+	root.ElasticSevenSslEnabled.Value = "true"
+	root.ElasticSevenSslEnabled.Sensitive = false
+
+	root.CosmosConnection.Value = secret("cosmos-connection")
+	root.CosmosConnection.Sensitive = true
+
+	root.CosmosEndpoint.Value = secret("cosmos-endpoint")
+	root.CosmosEndpoint.Sensitive = true
+
+	root.CosmosPrimaryKey.Value = secret("cosmos-primary-key")
+	root.CosmosPrimaryKey.Sensitive = true
+
+	root.SbConnection.Value = secret("sb-connection")
+	root.SbConnection.Sensitive = true
+
+	root.SbNamespace.Value = secret("sb-namespace")
+	root.SbNamespace.Sensitive = true
+
+	root.StorageAccountKey.Value = secret("storage-key")
+	root.StorageAccountKey.Sensitive = true
+
+	root.StorageAccountName.Value = secret("storage")
+	root.StorageAccountName.Sensitive = true
+
+	root.StorageAccountBlobEndpoint.Value = secret("storage-account-blob-endpoint")
+	root.StorageAccountBlobEndpoint.Sensitive = true
+
+	root.IngestStorageAccountName.Value = secret("ingest-storage")
+	root.IngestStorageAccountName.Sensitive = true
+
+	root.IngestStorageAccountKey.Value = secret("ingest-storage-key")
+	root.IngestStorageAccountKey.Sensitive = true
+
+	root.HierarchicalStorageAccountName.Value = secret("hierarchical-storage")
+	root.HierarchicalStorageAccountName.Sensitive = true
+
+	root.HierarchicalStorageAccountKey.Value = secret("hierarchical-storage-key")
+	root.HierarchicalStorageAccountKey.Sensitive = true
+
+	root.EventgridRecordstopic.Value = secret("eventgrid-recordstopic")
+	root.EventgridRecordstopic.Sensitive = true
+
+	root.EventgridRecordstopicAccesskey.Value = secret("eventgrid-recordstopic-accesskey")
+	root.EventgridRecordstopicAccesskey.Sensitive = true
+
+	root.EventgridLegaltagschangedtopic.Value = secret("eventgrid-legaltagschangedtopic")
+	root.EventgridLegaltagschangedtopic.Sensitive = true
+
+	root.EventgridLegaltagschangedtopicAccesskey.Value = secret("eventgrid-legaltagschangedtopic-accesskey")
+	root.EventgridLegaltagschangedtopicAccesskey.Sensitive = true
+
+	root.EventgridResourcegroup.Value = secret("eventgrid-resourcegroup")
+	root.EventgridResourcegroup.Sensitive = true
+
+	root.EncryptionKeyIdentifier.Value = secret("encryption-key-identifier")
+	root.EncryptionKeyIdentifier.Sensitive = true
+
+	// Seismic DMS storage is shared by the partitions
+	root.SdmsStorageAccountName.Value = "sdms-storage"
+	root.SdmsStorageAccountName.Sensitive = true
+
+	root.SdmsStorageAccountKey.Value = "sdms-storage-key"
+	root.SdmsStorageAccountKey.Sensitive = true
+
+	root.EventgridSchemanotificationtopic.Value = secret("eventgrid-schemachangedtopic")
+	root.EventgridSchemanotificationtopic.Sensitive = true
+
+	root.EventgridSchemanotificationtopicAccesskey.Value = secret("eventgrid-schemachangedtopic-accesskey")
+	root.EventgridSchemanotificationtopicAccesskey.Sensitive = true
+
+	root.EventgridGsmtopic.Value = secret("eventgrid-statuschangedtopic")
+	root.EventgridGsmtopic.Sensitive = true
+
+	root.EventgridGsmtopicAccesskey.Value = secret("eventgrid-statuschangedtopic-accesskey")
+	root.EventgridGsmtopicAccesskey.Sensitive = true
+
+	root.EventgridStatuschangedtopic.Value = secret("eventgrid-statuschangedtopic")
+	root.EventgridStatuschangedtopic.Sensitive = true
+
+	root.EventgridStatuschangedtopicAccesskey.Value = secret("eventgrid-statuschangedtopic-accesskey")
+	root.EventgridStatuschangedtopicAccesskey.Sensitive = true
+
+	root.EventgridSchemachangedtopic.Value = secret("eventgrid-schemachangedtopic")
+	root.EventgridSchemachangedtopic.Sensitive = true
+
+	root.EventgridSchemachangedtopicAccesskey.Value = secret("eventgrid-schemachangedtopic-accesskey")
+	root.EventgridSchemachangedtopicAccesskey.Sensitive = true
+
+	root.IndexerDecimationEnabled.Value = "true"
+	root.IndexerDecimationEnabled.Sensitive = false
+
+	return root
+}
+
+// GetDefaultPartitionPropertiesAWS follows the AWS convention: sensitive values are the
+// names of Secrets Manager secrets under /osdu/<partition_id>/
+func GetDefaultPartitionPropertiesAWS(partition_id string) PartitionProperties {
+	slog.Info(fmt.Sprintf("Gathering default values for partition AWS %s", partition_id))
+	root := PartitionProperties{}
+	secret := func(name string) string {
+		return fmt.Sprintf("/osdu/%s/%s", partition_id, name)
+	}
+	bucket_prefix := fmt.Sprintf("osdu-%s", partition_id)
+
+	root.DataPartitionId.Value = partition_id
+	root.DataPartitionId.Sensitive = false
+
+	root.Name.Value = partition_id
+	root.Name.Sensitive = false
+
+	root.ComplianceRuleSet.Value = "shared"
+	root.ComplianceRuleSet.Sensitive = false
+
+	root.LegalBucketName.Value = fmt.Sprintf("%s-legal", bucket_prefix)
+	root.LegalBucketName.Sensitive = false
+
+	root.StorageBucketName.Value = fmt.Sprintf("%s-records", bucket_prefix)
+	root.StorageBucketName.Sensitive = false
+
+	root.SchemaBucketName.Value = fmt.Sprintf("%s-schema", bucket_prefix)
+	root.SchemaBucketName.Sensitive = false
+
+	root.FileStagingLocation.Value = fmt.Sprintf("%s-file-staging", bucket_prefix)
+	root.FileStagingLocation.Sensitive = false
+
+	root.FilePersistenLocation.Value = fmt.Sprintf("%s-file-persistent", bucket_prefix)
+	root.FilePersistenLocation.Sensitive = false
+
+	root.ElasticsearchHost.Value = secret("elasticsearch/host")
+	root.ElasticsearchHost.Sensitive = true
+
+	root.ElasticsearchPort.Value = secret("elasticsearch/port")
+	root.ElasticsearchPort.Sensitive = true
+
+	root.ElasticsearchUser.Value = secret("elasticsearch/username")
+	root.ElasticsearchUser.Sensitive = true
+
+	root.ElasticsearchPassword.Value = secret("elasticsearch/password")
+	root.ElasticsearchPassword.Sensitive = true
+
+	root.ElasticsearchHttps.Value = "true"
+	root.ElasticsearchHttps.Sensitive = false
+
+	root.IndexAugmenterEnabled.Value = "false"
+	root.IndexAugmenterEnabled.Sensitive = false
+
+	root.FeatureFlagPolicyEnabled.Value = "false"
+	root.FeatureFlagPolicyEnabled.Sensitive = false
+
+	return root
+}
+
+// GetDefaultPartitionPropertiesGCP follows the Google Cloud convention: sensitive values are the
+// names of environment variables suffixed by the partition id in capitals
+func GetDefaultPartitionPropertiesGCP(partition_id string) PartitionProperties {
+	slog.Info(fmt.Sprintf("Gathering default values for partition GCP %s", partition_id))
+	root := PartitionProperties{}
+	suffix_capital := strings.ToUpper(partition_id)
+
+	bucket_prefix := fmt.Sprintf("osdu-%s", partition_id)
+
+	root.ProjectId.Value = "osdu"
+	root.ProjectId.Sensitive = false
+
+	root.ServiceAccount.Value = "datafier@osdu.iam.gserviceaccount.com"
+	root.ServiceAccount.Sensitive = false
+
+	root.ComplianceRuleSet.Value = "shared"
+	root.ComplianceRuleSet.Sensitive = false
+
+	root.DataPartitionId.Value = partition_id
+	root.DataPartitionId.Sensitive = false
+
+	root.Name.Value = partition_id
+	root.Name.Sensitive = false
+
+	root.Bucket.Value = fmt.Sprintf("%s-records", bucket_prefix)
+	root.Bucket.Sensitive = false
+
+	root.CrmAccountID.Value = fmt.Sprintf("[%s,%s]", partition_id, partition_id)
+	root.CrmAccountID.Sensitive = false
+
+	root.OsmPostgresDatasourceUrl.Value = fmt.Sprintf("POSTGRES_DATASOURCE_URL_%s", suffix_capital)
+	root.OsmPostgresDatasourceUrl.Sensitive = true
+
+	root.OsmPostgresDatasourceUsername.Value = fmt.Sprintf("POSTGRES_DB_USERNAME_%s", suffix_capital)
+	root.OsmPostgresDatasourceUsername.Sensitive = true
+
+	root.OsmPostgresDatasourcePassword.Value = fmt.Sprintf("POSTGRES_DB_PASSWORD_%s", suffix_capital)
+	root.OsmPostgresDatasourcePassword.Sensitive = true
+
+	root.KubernetesSecretName.Value = "eds-osdu"
+	root.KubernetesSecretName.Sensitive = false
+
+	root.ElasticsearchHost.Value = fmt.Sprintf("ELASTIC_HOST_%s", suffix_capital)
+	root.ElasticsearchHost.Sensitive = true
+
+	root.ElasticsearchPort.Value = fmt.Sprintf("ELASTIC_PORT_%s", suffix_capital)
+	root.ElasticsearchPort.Sensitive = true
+
+	root.ElasticsearchUser.Value = fmt.Sprintf("ELASTIC_USER_%s", suffix_capital)
+	root.ElasticsearchUser.Sensitive = true
+
+	root.ElasticsearchPassword.Value = fmt.Sprintf("ELASTIC_PASS_%s", suffix_capital)
+	root.ElasticsearchPassword.Sensitive = true
+
+	root.ElasticsearchHttps.Value = "true"
+	root.ElasticsearchHttps.Sensitive = false
+
+	root.IndexAugmenterEnabled.Value = "false"
+	root.IndexAugmenterEnabled.Sensitive = false
+
+	root.FeatureFlagPolicyEnabled.Value = "false"
+	root.FeatureFlagPolicyEnabled.Sensitive = false
+
+	return root
+}
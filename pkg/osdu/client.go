@@ -3,7 +3,6 @@ package osdu
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -25,16 +24,67 @@ const (
 type OsduApiRequest struct {
 	authProvider auth.AuthProvider
 	osduSettings config.OsduSettings
+	internal     *config.InternalSettings
+	httpClient   *http.Client
+	logger       *slog.Logger
+	retryPolicy  *RetryPolicy
+	userAgent    string
+	// live is shared by the copies of a reloadable client, see NewReloadableClient
+	live *liveConfig
 }
 
-// NewClient creates a new OSDU API client with the appropriate authentication provider
+// NewClient creates a new OSDU API client with the appropriate authentication provider.
+// Configuration errors are only logged, use LoadClient or NewClientFromConfig to fail fast.
 func NewClient() OsduApiRequest {
-	osduSettings, _ := config.GetOsduSettings()
-	authSettings, _ := config.GetAuthSettings()
-
 	// Get the full configuration to determine provider
-	configFile := config.GetConfigFile()
-	cfg, _ := config.GetConfig(configFile)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load configuration: %s", err))
+	} else if err := cfg.Validate(); err != nil {
+		slog.Warn(err.Error())
+	}
+	return newClientFromConfig(cfg)
+}
+
+// LoadClient loads CONFIG_FILE with the environment overlay and creates a validated client
+func LoadClient() (OsduApiRequest, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return OsduApiRequest{}, err
+	}
+	return NewClientFromConfig(cfg)
+}
+
+// NewClientWithProfile creates a new OSDU API client from a named profile of CONFIG_FILE
+func NewClientWithProfile(profile string) (OsduApiRequest, error) {
+	cfg, err := config.LoadConfigProfile(profile)
+	if err != nil {
+		return OsduApiRequest{}, err
+	}
+	return NewClientFromConfig(cfg)
+}
+
+// NewClientFromConfig validates cfg and creates a client, failing when the configuration
+// is invalid or the authentication provider cannot be created
+func NewClientFromConfig(cfg config.Config) (OsduApiRequest, error) {
+	if err := cfg.Validate(); err != nil {
+		return OsduApiRequest{}, err
+	}
+
+	authProvider, err := auth.NewProviderFactory().GetProviderFromConfig(cfg.OsduClient)
+	if err != nil {
+		return OsduApiRequest{}, fmt.Errorf("failed to create auth provider: %w", err)
+	}
+
+	client := NewClientWithConfig(authProvider, cfg.OsduClient.OsduSettings)
+	if cfg.OsduClient.InternalService {
+		return client.WithInternalService(cfg.OsduClient.Internal), nil
+	}
+	return client, nil
+}
+
+func newClientFromConfig(cfg config.Config) OsduApiRequest {
+	authSettings := cfg.OsduClient.AuthSettings
 
 	// Create authentication provider using factory
 	factory := auth.NewProviderFactory()
@@ -45,13 +95,19 @@ func NewClient() OsduApiRequest {
 		authProvider = auth.NewOpenIDProvider(authSettings)
 	}
 
-	return OsduApiRequest{
+	client := OsduApiRequest{
 		authProvider: authProvider,
-		osduSettings: osduSettings,
+		osduSettings: cfg.OsduClient.OsduSettings,
 	}
+
+	if authSettings.InternalService {
+		return client.WithInternalService(authSettings.Internal)
+	}
+	return client
 }
 
-// NewClientWithProvider creates a new OSDU API client with a specific authentication provider
+// NewClientWithProvider creates a new OSDU API client with a specific authentication provider.
+// The client settings are still read from CONFIG_FILE, use New with WithAuthProvider to avoid it.
 func NewClientWithProvider(provider auth.AuthProvider) OsduApiRequest {
 	osduSettings, _ := config.GetOsduSettings()
 
@@ -69,6 +125,15 @@ func NewClientWithConfig(provider auth.AuthProvider, osduSettings config.OsduSet
 	}
 }
 
+// WithInternalService returns a copy of the client in internal (in-cluster) mode.
+// Service URLs are replaced by their Kubernetes service URL when configured, identity headers
+// are sent on every request and services marked with skipToken are called without a token.
+func (a OsduApiRequest) WithInternalService(internal config.InternalSettings) OsduApiRequest {
+	a.osduSettings = internal.ResolveUrls(a.osduSettings)
+	a.internal = &internal
+	return a
+}
+
 func (a OsduApiRequest) Context() context.Context {
 	return context.Background()
 }
@@ -78,12 +143,17 @@ func (a OsduApiRequest) Workflow() WorkflowService {
 	return NewWorkflowService(&a)
 }
 
+// Partition returns a PartitionService interface for partition operations
+func (a OsduApiRequest) Partition() PartitionService {
+	return NewPartitionService(&a)
+}
+
 func (a OsduApiRequest) NewRequest(operation string, url string, partitionid string, body []byte) ([]byte, error) {
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	a = a._current()
 	req, _ := http.NewRequest(operation, url, bytes.NewBuffer(body))
-	headers, _ := a._build_headers_with_partition()
+	headers, _ := a._build_headers_with_partition("")
 	req.Header = headers
-	c := http.Client{}
+	c := a._http_client()
 	res, err := c.Do(req)
 	if err != nil {
 		return nil, err
@@ -93,29 +163,69 @@ func (a OsduApiRequest) NewRequest(operation string, url string, partitionid str
 		return resBody, err
 	}
 	res.Body.Close()
-	slog.Debug("Response:")
+	a._logger().Debug("Response:")
 	return resBody, nil
 }
 
-func (a OsduApiRequest) _build_headers_with_partition() (http.Header, error) {
-	slog.Debug(fmt.Sprintf("Partition Header - data-partition-id : %s", a.osduSettings.PartitionId))
+// _skip_token reports whether service is called without token in internal mode
+func (a OsduApiRequest) _skip_token(service string) bool {
+	return a.internal != nil && service != "" && a.internal.SkipToken(service)
+}
+
+// _add_internal_headers adds the service-to-service identity headers in internal mode
+func (a OsduApiRequest) _add_internal_headers(headers http.Header) http.Header {
+	if a.internal == nil {
+		return headers
+	}
+	for k, v := range a.internal.IdentityHeaders() {
+		headers.Set(k, v)
+	}
+	return headers
+}
+
+// _add_client_headers adds the User-Agent and the internal mode headers
+func (a OsduApiRequest) _add_client_headers(headers http.Header) http.Header {
+	if a.userAgent != "" {
+		headers.Set("User-Agent", a.userAgent)
+	}
+	return a._add_internal_headers(headers)
+}
+
+func (a OsduApiRequest) _build_headers_with_partition(service string) (http.Header, error) {
+	a._logger().Debug(fmt.Sprintf("Partition Header - data-partition-id : %s", a.osduSettings.PartitionId))
 	if len(a.osduSettings.PartitionId) < 2 {
 		return http.Header{}, errors.New("invalid partition id")
 	}
+
+	if a._skip_token(service) {
+		a._logger().Debug(fmt.Sprintf("Internal service %s - skipping token", service))
+		return a._add_client_headers(http.Header{
+			"Content-Type":      {"application/json"},
+			"data-partition-id": {a.osduSettings.PartitionId},
+		}), nil
+	}
+
 	token, err := a.authProvider.GetAccessToken(a.Context())
 	if err != nil {
 		return http.Header{}, err
 	}
 
-	slog.Debug(fmt.Sprintf("Authorization Header - Authorization: Bearer %s", token.AccessToken))
-	return http.Header{
+	a._logger().Debug(fmt.Sprintf("Authorization Header - Authorization: Bearer %s", token.AccessToken))
+	return a._add_client_headers(http.Header{
 		"Content-Type":      {"application/json"},
 		"Authorization":     {fmt.Sprintf("Bearer %s", token.AccessToken)},
 		"data-partition-id": {a.osduSettings.PartitionId},
-	}, nil
+	}), nil
 }
 
-func (a OsduApiRequest) _build_headers_without_partition() (http.Header, error) {
+func (a OsduApiRequest) _build_headers_without_partition(service string) (http.Header, error) {
+	if a._skip_token(service) {
+		a._logger().Debug(fmt.Sprintf("Internal service %s - skipping token", service))
+		return a._add_client_headers(http.Header{
+			"Content-Type": {"application/json"},
+		}), nil
+	}
+
 	token, err := a.authProvider.GetAccessToken(a.Context())
 	if err != nil {
 		log.Println(err)
@@ -125,33 +235,37 @@ func (a OsduApiRequest) _build_headers_without_partition() (http.Header, error)
 	// If no access token is provided (empty), return headers without authorization
 	if token == nil || token.AccessToken == "" {
 		ctx := context.Background()
-		slog.InfoContext(ctx, "No access token provided, proceeding without authorization")
-		return http.Header{
+		a._logger().InfoContext(ctx, "No access token provided, proceeding without authorization")
+		return a._add_client_headers(http.Header{
 			"Content-Type": {"application/json"},
-		}, nil
+		}), nil
 	}
 
-	return http.Header{
+	return a._add_client_headers(http.Header{
 		"Content-Type":  {"application/json"},
 		"Authorization": {fmt.Sprintf("Bearer %s", token.AccessToken)},
-	}, nil
+	}), nil
 }
 
 // HttpRequestWithoutPartition makes an HTTP request without the data-partition-id header
 func (a OsduApiRequest) HttpRequestWithoutPartition(method, url string, body []byte) (*http.Response, error) {
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	a = a._current()
+	return a._http_request_without_partition("", method, url, body)
+}
+
+func (a OsduApiRequest) _http_request_without_partition(service, method, url string, body []byte) (*http.Response, error) {
 
 	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
 
-	headers, err := a._build_headers_without_partition()
+	headers, err := a._build_headers_without_partition(service)
 	if err != nil {
 		return nil, err
 	}
 	req.Header = headers
 
-	client := http.Client{}
+	client := a._http_client()
 	return client.Do(req)
 }
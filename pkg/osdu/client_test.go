@@ -100,3 +100,30 @@ func TestMockAuthenticationFailure(t *testing.T) {
 	// The current implementation logs the auth error but continues, so we get a network error
 	assert.Contains(t, err.Error(), "mock-partition")
 }
+
+func TestNewClientFromConfig(t *testing.T) {
+	cfg := config.Config{OsduClient: config.OsduClient{
+		Provider: "openid",
+		AuthSettings: config.AuthSettings{
+			ClientId:  "datafier",
+			TokenUrl:  "https://keycloak/realms/osdu/protocol/openid-connect/token",
+			GrantType: "client_credentials",
+		},
+		OsduSettings: config.OsduSettings{
+			PartitionId:     "opendes",
+			PartitionUrl:    "https://osdu/api/partition/v1",
+			EntitlementsUrl: "https://osdu/api/entitlements/v2",
+		},
+	}}
+
+	client, err := osdu.NewClientFromConfig(cfg)
+	assert.NoError(t, err)
+	assert.NotNil(t, client.Workflow())
+
+	// Fails fast instead of creating a client with empty URLs
+	cfg.OsduClient.PartitionUrl = ""
+	cfg.OsduClient.Provider = "keycloak"
+	_, err = osdu.NewClientFromConfig(cfg)
+	assert.ErrorContains(t, err, "osdu.client.partitionUrl: is required")
+	assert.ErrorContains(t, err, `osdu.provider: unknown provider "keycloak"`)
+}
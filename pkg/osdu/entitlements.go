@@ -3,26 +3,44 @@ package osdu
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	retry "github.com/avast/retry-go"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
 	"github.com/heba920908/osdu-sdk-go/pkg/models"
 )
 
-func (a OsduApiRequest) EntitlementsBootstrap() error {
+// EntitlementsBootstrap provisions the entitlements tenant of the partition with mappings, the
+// mappings of the entitlementsBootstrap settings when none is given
+func (a OsduApiRequest) EntitlementsBootstrap(mappings ...models.EntitlementsBoostrapUser) error {
+	a = a._current()
 	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	if len(mappings) == 0 {
+		users, err := a._bootstrap_users()
+		if err != nil {
+			return err
+		}
+		mappings = users
+	} else {
+		if err := models.ValidateEntitlementsBootstrapUsers(mappings); err != nil {
+			return err
+		}
+		mappings = append([]models.EntitlementsBoostrapUser{}, mappings...)
+		models.SortEntitlementsBootstrapUsers(mappings)
+	}
 
 	bootstrap_url := fmt.Sprintf("%s/tenant-provisioning", a.osduSettings.EntitlementsUrl)
 	boostrap_request := models.EntitlementsBootstrapRequest{
-		AliasMappings: models.DefaultEntitlementsBootstrapUsers(),
+		AliasMappings: mappings,
 	}
 
 	json_content, err := json.Marshal(boostrap_request)
@@ -31,51 +49,61 @@ func (a OsduApiRequest) EntitlementsBootstrap() error {
 	}
 
 	j, _ := json.MarshalIndent(boostrap_request, "", "  ")
-	slog.Info(string(j))
+	a._logger().Info(string(j))
 
 	err = retry.Do(
 		func() error {
 			req, _ := http.NewRequest("POST", bootstrap_url, bytes.NewBuffer([]byte(json_content)))
-			headers, err := a._build_headers_with_partition()
+			headers, err := a._build_headers_with_partition(config.ServiceEntitlements)
 			if err != nil {
 				return err
 			}
 
 			req.Header = headers
 
-			http_client := http.Client{}
+			http_client := a._http_client()
 
 			res, err := http_client.Do(req)
 			if err != nil {
-				slog.ErrorContext(ctx, err.Error())
+				a._logger().ErrorContext(ctx, err.Error())
 				return err
 			}
-			slog.InfoContext(ctx, fmt.Sprintf("Entitlements Boostrap Code: %d", res.StatusCode))
+			a._logger().InfoContext(ctx, fmt.Sprintf("Entitlements Boostrap Code: %d", res.StatusCode))
 			defer res.Body.Close()
 			body, err := io.ReadAll(res.Body)
 			if err != nil {
-				slog.ErrorContext(ctx, err.Error())
+				a._logger().ErrorContext(ctx, err.Error())
 			}
-			slog.DebugContext(ctx, string(body))
+			a._logger().DebugContext(ctx, string(body))
 			if res.StatusCode != http.StatusOK {
 				return errors.New("not 200 response")
 			}
 			return nil
 		},
-		retry.Attempts(3),
-		retry.Delay(10*time.Second),
+		a._retry_attempts(3),
+		a._retry_delay(10*time.Second),
 		retry.OnRetry(func(n uint, err error) {
-			slog.WarnContext(ctx, fmt.Sprintf("retry #%d: %s\n", n, err))
+			a._logger().WarnContext(ctx, fmt.Sprintf("retry #%d: %s\n", n, err))
 		}),
 	)
 
 	return err
 }
 
+// _bootstrap_users returns the alias mappings of the entitlementsBootstrap settings
+func (a OsduApiRequest) _bootstrap_users() ([]models.EntitlementsBoostrapUser, error) {
+	settings := a.osduSettings.EntitlementsBootstrap
+	mappings := make([]models.EntitlementsBoostrapUser, 0, len(settings.AliasMappings))
+	for _, mapping := range settings.AliasMappings {
+		mappings = append(mappings, models.EntitlementsBoostrapUser{AliasId: mapping.AliasId, UserId: mapping.UserId})
+	}
+	return models.ResolveEntitlementsBootstrapUsers(settings.Replace, settings.File, mappings)
+}
+
 func (a OsduApiRequest) EntitlementsCreateAdminUser(user_email string) error {
+	a = a._current()
 	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	slog.InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] User: %s",
+	a._logger().InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] User: %s",
 		user_email))
 
 	add_user_request := models.EntitlementsAddUserRequest{
@@ -91,9 +119,9 @@ func (a OsduApiRequest) EntitlementsCreateAdminUser(user_email string) error {
 	entitlement_groups := []string{"users", "users.datalake.ops", "users.datalake.admins"}
 
 	j, _ := json.MarshalIndent(add_user_request, "", "  ")
-	slog.InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] Payload: %s", string(j)))
+	a._logger().InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] Payload: %s", string(j)))
 
-	headers, _ := a._build_headers_with_partition()
+	headers, _ := a._build_headers_with_partition(config.ServiceEntitlements)
 
 	err = retry.Do(
 		func() error {
@@ -104,36 +132,36 @@ func (a OsduApiRequest) EntitlementsCreateAdminUser(user_email string) error {
 					a.osduSettings.PartitionId,
 					a.osduSettings.EntitlementsDomain)
 				req, _ := http.NewRequest("POST", entitlements_url, bytes.NewBuffer([]byte(json_content)))
-				slog.InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] POST: %s", entitlements_url))
+				a._logger().InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] POST: %s", entitlements_url))
 				req.Header = headers
 
-				http_client := http.Client{}
+				http_client := a._http_client()
 
 				res, err := http_client.Do(req)
 				if err != nil {
-					slog.Error(err.Error())
+					a._logger().Error(err.Error())
 					return err
 				}
-				slog.InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] User: %s | Group: %s | Code: %d",
+				a._logger().InfoContext(ctx, fmt.Sprintf("[CreateEntitlementsAdminUser] User: %s | Group: %s | Code: %d",
 					user_email,
 					group,
 					res.StatusCode))
 				defer res.Body.Close()
 				body, err := io.ReadAll(res.Body)
 				if err != nil {
-					slog.Error(err.Error())
+					a._logger().Error(err.Error())
 				}
-				slog.DebugContext(ctx, string(body))
+				a._logger().DebugContext(ctx, string(body))
 				if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusConflict {
 					return errors.New("not 200 nor 409 response")
 				}
 			}
 			return nil
 		},
-		retry.Attempts(3),
-		retry.Delay(10*time.Second),
+		a._retry_attempts(3),
+		a._retry_delay(10*time.Second),
 		retry.OnRetry(func(n uint, err error) {
-			slog.WarnContext(ctx, fmt.Sprintf("retry #%d: %s\n", n, err))
+			a._logger().WarnContext(ctx, fmt.Sprintf("retry #%d: %s\n", n, err))
 		}),
 	)
 
@@ -141,15 +169,34 @@ func (a OsduApiRequest) EntitlementsCreateAdminUser(user_email string) error {
 }
 
 func (a OsduApiRequest) EntitlementsCreateGroup(group_id string, user_ids []string) error {
+	a = a._current()
 	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 
-	slog.InfoContext(ctx, fmt.Sprintf("Create Group %s", group_id))
+	if err := a._create_group(ctx, group_id, ""); err != nil {
+		return err
+	}
+
+	// Add users to the group
+	for _, user := range user_ids {
+		if err := a._create_owner_member_group(group_id, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// _create_group creates group_id with description, "Group <id> bootstrapped" when empty. A group
+// that already exists is not an error.
+func (a OsduApiRequest) _create_group(ctx context.Context, group_id string, description string) error {
+	a._logger().InfoContext(ctx, fmt.Sprintf("Create Group %s", group_id))
 	create_group_url := fmt.Sprintf("%s/groups", a.osduSettings.EntitlementsUrl)
 
+	if description == "" {
+		description = fmt.Sprintf("Group %s bootstrapped", group_id)
+	}
 	request_body := models.EntitlementsCreateGroupRequest{
 		GroupName:   group_id,
-		Description: fmt.Sprintf("Group %s bootstrapped", group_id),
+		Description: description,
 	}
 
 	json_content, err := json.Marshal(request_body)
@@ -158,40 +205,81 @@ func (a OsduApiRequest) EntitlementsCreateGroup(group_id string, user_ids []stri
 	}
 
 	j, _ := json.MarshalIndent(request_body, "", "  ")
-	slog.InfoContext(ctx, fmt.Sprintf("Create Group URL: %s", create_group_url))
-	slog.DebugContext(ctx, string(j))
+	a._logger().InfoContext(ctx, fmt.Sprintf("Create Group URL: %s", create_group_url))
+	a._logger().DebugContext(ctx, string(j))
 
-	err = retry.Do(
+	_, err = a._entitlements_request(ctx, http.MethodPost, create_group_url, json_content)
+	if errors.Is(err, ErrEntitlementsConflict) {
+		a._logger().WarnContext(ctx, fmt.Sprintf("Group %s already exists", group_id))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Created GroupId: %s", group_id))
+	return nil
+}
+
+func (a OsduApiRequest) _create_owner_member_group(group_id, user_id string) error {
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+	entitlements_group := fmt.Sprintf("%s@%s.%s",
+		group_id,
+		a.osduSettings.PartitionId,
+		a.osduSettings.EntitlementsDomain,
+	)
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("[Entitlements] Create Owner Member routine - UserId: %s | GroupId: %s", user_id, entitlements_group))
+
+	add_user_url := fmt.Sprintf("%s/groups/%s/members",
+		a.osduSettings.EntitlementsUrl,
+		entitlements_group,
+	)
+	request_body := models.EntitlementsAddUserRequest{
+		Email: user_id,
+		Role:  "OWNER",
+	}
+
+	json_content, err := json.Marshal(request_body)
+	if err != nil {
+		return err
+	}
+
+	j, _ := json.MarshalIndent(request_body, "", "  ")
+	a._logger().InfoContext(ctx, fmt.Sprintf("Add user URL: %s", add_user_url))
+	a._logger().DebugContext(ctx, string(j))
+
+	return retry.Do(
 		func() error {
-			req, err := http.NewRequest("POST", create_group_url, bytes.NewBuffer(json_content))
+			req, err := http.NewRequest("POST", add_user_url, bytes.NewBuffer(json_content))
 			if err != nil {
 				return err
 			}
 
-			headers, err := a._build_headers_with_partition()
+			headers, err := a._build_headers_with_partition(config.ServiceEntitlements)
 			if err != nil {
 				return err
 			}
 			req.Header = headers
 
-			http_client := http.Client{}
+			http_client := a._http_client()
 			res, err := http_client.Do(req)
 			if err != nil {
-				slog.ErrorContext(ctx, err.Error())
+				a._logger().ErrorContext(ctx, err.Error())
 				return err
 			}
 			defer res.Body.Close()
 
 			body, err := io.ReadAll(res.Body)
 			if err != nil {
-				slog.ErrorContext(ctx, err.Error())
+				a._logger().ErrorContext(ctx, err.Error())
 			}
-			slog.DebugContext(ctx, string(body))
+			a._logger().DebugContext(ctx, string(body))
 
-			slog.InfoContext(ctx, fmt.Sprintf("Created GroupId: %s | Entitlements Response: %d", group_id, res.StatusCode))
+			a._logger().InfoContext(ctx, fmt.Sprintf("[Entitlements] OWNER Member Created - UserId: %s | GroupId: %s | Entitlements Response: %d", user_id, entitlements_group, res.StatusCode))
 
 			if res.StatusCode == http.StatusConflict {
-				slog.WarnContext(ctx, fmt.Sprintf("Group %s already exists", group_id))
+				a._logger().WarnContext(ctx, fmt.Sprintf("User %s already exists in group %s", user_id, entitlements_group))
 				return nil
 			}
 
@@ -200,97 +288,283 @@ func (a OsduApiRequest) EntitlementsCreateGroup(group_id string, user_ids []stri
 			}
 			return nil
 		},
-		retry.Attempts(3),
-		retry.Delay(5*time.Second),
+		a._retry_attempts(3),
+		a._retry_delay(5*time.Second),
 		retry.OnRetry(func(n uint, err error) {
-			slog.WarnContext(ctx, fmt.Sprintf("retry #%d: %s", n, err))
+			a._logger().WarnContext(ctx, fmt.Sprintf("retry #%d: %s", n, err))
 		}),
 	)
+}
 
-	if err != nil {
-		return err
+// Errors wrapped by the entitlements service responses, when the group or member does not exist
+// and when it already exists
+var (
+	ErrEntitlementsNotFound = errors.New("entitlements: not found")
+	ErrEntitlementsConflict = errors.New("entitlements: already exists")
+)
+
+// ListMyGroups returns the groups of the caller (GET /groups)
+func (a OsduApiRequest) ListMyGroups() (models.EntitlementsGroupsResponse, error) {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+
+	var groups models.EntitlementsGroupsResponse
+	list_url := fmt.Sprintf("%s/groups", a.osduSettings.EntitlementsUrl)
+	if err := a._entitlements_get(ctx, list_url, &groups); err != nil {
+		return models.EntitlementsGroupsResponse{}, err
 	}
+	return groups, nil
+}
 
-	// Add users to the group
-	for _, user := range user_ids {
-		if err := a._create_owner_member_group(group_id, user); err != nil {
-			return err
+// ListGroupMembers returns every member of group, following the pages of options.Limit members.
+// group is a group email or a group name of the current partition, i.e users.datalake.admins.
+func (a OsduApiRequest) ListGroupMembers(group string, options models.EntitlementsListMembersOptions) ([]models.EntitlementsMember, error) {
+	// Every page is read on the same snapshot, a cursor is only valid for the service it came from
+	a = a._current()
+	a.live = nil
+
+	var members []models.EntitlementsMember
+	for {
+		page, err := a.ListGroupMembersPage(group, options)
+		if err != nil {
+			return nil, err
 		}
+		members = append(members, page.Members...)
+		if page.Cursor == "" || page.Cursor == options.Cursor {
+			return members, nil
+		}
+		options.Cursor = page.Cursor
 	}
-	return nil
 }
 
-func (a OsduApiRequest) _create_owner_member_group(group_id, user_id string) error {
+// ListGroupMembersPage returns a single page of the members of group (GET /groups/{email}/members)
+func (a OsduApiRequest) ListGroupMembersPage(group string, options models.EntitlementsListMembersOptions) (models.EntitlementsMembersResponse, error) {
+	a = a._current()
 	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
-	entitlements_group := fmt.Sprintf("%s@%s.%s",
-		group_id,
-		a.osduSettings.PartitionId,
-		a.osduSettings.EntitlementsDomain,
-	)
 
-	slog.InfoContext(ctx, fmt.Sprintf("[Entitlements] Create Owner Member routine - UserId: %s | GroupId: %s", user_id, entitlements_group))
+	query := url.Values{}
+	if options.Role != "" {
+		query.Set("role", options.Role)
+	}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	}
+	members_url := fmt.Sprintf("%s/groups/%s/members", a.osduSettings.EntitlementsUrl, url.PathEscape(a._group_email(group)))
+	if len(query) > 0 {
+		members_url = fmt.Sprintf("%s?%s", members_url, query.Encode())
+	}
 
-	add_user_url := fmt.Sprintf("%s/groups/%s/members",
-		a.osduSettings.EntitlementsUrl,
-		entitlements_group,
-	)
-	request_body := models.EntitlementsAddUserRequest{
-		Email: user_id,
-		Role:  "OWNER",
+	var page models.EntitlementsMembersResponse
+	if err := a._entitlements_get(ctx, members_url, &page); err != nil {
+		return models.EntitlementsMembersResponse{}, err
 	}
+	return page, nil
+}
 
-	json_content, err := json.Marshal(request_body)
+// ListMemberGroups returns the groups of member_email of group_type (GET /members/{email}/groups),
+// every group when group_type is empty or models.EntitlementsGroupTypeNone
+func (a OsduApiRequest) ListMemberGroups(member_email string, group_type string) (models.EntitlementsGroupsResponse, error) {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+
+	if group_type == "" {
+		group_type = models.EntitlementsGroupTypeNone
+	}
+	groups_url := fmt.Sprintf("%s/members/%s/groups?type=%s",
+		a.osduSettings.EntitlementsUrl, url.PathEscape(member_email), url.QueryEscape(group_type))
+
+	var groups models.EntitlementsGroupsResponse
+	if err := a._entitlements_get(ctx, groups_url, &groups); err != nil {
+		return models.EntitlementsGroupsResponse{}, err
+	}
+	return groups, nil
+}
+
+// _group_email qualifies a group name with the partition and entitlements domain, emails are kept
+func (a OsduApiRequest) _group_email(group string) string {
+	if strings.Contains(group, "@") {
+		return group
+	}
+	return fmt.Sprintf("%s@%s.%s", group, a.osduSettings.PartitionId, a.osduSettings.EntitlementsDomain)
+}
+
+// _entitlements_get decodes the response of a GET into v
+func (a OsduApiRequest) _entitlements_get(ctx context.Context, get_url string, v interface{}) error {
+	body, err := a._entitlements_request(ctx, http.MethodGet, get_url, nil)
 	if err != nil {
 		return err
 	}
+	return json.Unmarshal(body, v)
+}
 
-	j, _ := json.MarshalIndent(request_body, "", "  ")
-	slog.InfoContext(ctx, fmt.Sprintf("Add user URL: %s", add_user_url))
-	slog.DebugContext(ctx, string(j))
+// _entitlements_request returns the response body of a request, client errors are not retried
+func (a OsduApiRequest) _entitlements_request(ctx context.Context, method, request_url string, json_content []byte) ([]byte, error) {
+	a._logger().DebugContext(ctx, fmt.Sprintf("%s %s", method, request_url))
 
-	return retry.Do(
+	var body []byte
+	err := retry.Do(
 		func() error {
-			req, err := http.NewRequest("POST", add_user_url, bytes.NewBuffer(json_content))
+			req, err := http.NewRequest(method, request_url, bytes.NewBuffer(json_content))
 			if err != nil {
-				return err
+				return retry.Unrecoverable(err)
 			}
 
-			headers, err := a._build_headers_with_partition()
+			headers, err := a._build_headers_with_partition(config.ServiceEntitlements)
 			if err != nil {
-				return err
+				return retry.Unrecoverable(err)
 			}
 			req.Header = headers
 
-			http_client := http.Client{}
+			http_client := a._http_client()
 			res, err := http_client.Do(req)
 			if err != nil {
-				slog.ErrorContext(ctx, err.Error())
+				a._logger().ErrorContext(ctx, err.Error())
 				return err
 			}
 			defer res.Body.Close()
 
-			body, err := io.ReadAll(res.Body)
+			body, err = io.ReadAll(res.Body)
 			if err != nil {
-				slog.ErrorContext(ctx, err.Error())
-			}
-			slog.DebugContext(ctx, string(body))
-
-			slog.InfoContext(ctx, fmt.Sprintf("[Entitlements] OWNER Member Created - UserId: %s | GroupId: %s | Entitlements Response: %d", user_id, entitlements_group, res.StatusCode))
-
-			if res.StatusCode == http.StatusConflict {
-				slog.WarnContext(ctx, fmt.Sprintf("User %s already exists in group %s", user_id, entitlements_group))
-				return nil
+				return err
 			}
-
-			if res.StatusCode > http.StatusCreated {
-				return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+			a._logger().DebugContext(ctx, string(body))
+
+			switch {
+			case res.StatusCode == http.StatusNotFound:
+				return retry.Unrecoverable(fmt.Errorf("%w: %s %s", ErrEntitlementsNotFound, method, request_url))
+			case res.StatusCode == http.StatusConflict:
+				return retry.Unrecoverable(fmt.Errorf("%w: %s %s", ErrEntitlementsConflict, method, request_url))
+			case res.StatusCode >= 400 && res.StatusCode < 500:
+				return retry.Unrecoverable(fmt.Errorf("entitlements service response - %d : %s", res.StatusCode, string(body)))
+			case res.StatusCode > 299:
+				return fmt.Errorf("entitlements service response - %d : %s", res.StatusCode, string(body))
 			}
 			return nil
 		},
-		retry.Attempts(3),
-		retry.Delay(5*time.Second),
+		a._retry_attempts(3),
+		a._retry_delay(5*time.Second),
+		retry.LastErrorOnly(true),
 		retry.OnRetry(func(n uint, err error) {
-			slog.WarnContext(ctx, fmt.Sprintf("retry #%d: %s", n, err))
+			a._logger().WarnContext(ctx, fmt.Sprintf("retry #%d: %s", n, err))
 		}),
 	)
+	return body, err
+}
+
+// EntitlementsRemoveMember removes member_email from group, a member already absent is not an error
+func (a OsduApiRequest) EntitlementsRemoveMember(group string, member_email string) error {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+
+	group_email := a._group_email(group)
+	remove_url := fmt.Sprintf("%s/groups/%s/members/%s",
+		a.osduSettings.EntitlementsUrl, url.PathEscape(group_email), url.PathEscape(member_email))
+
+	_, err := a._entitlements_request(ctx, http.MethodDelete, remove_url, nil)
+	if errors.Is(err, ErrEntitlementsNotFound) {
+		a._logger().WarnContext(ctx, fmt.Sprintf("Member %s not found in group %s", member_email, group_email))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Member %s removed from group %s", member_email, group_email))
+	return nil
+}
+
+// EntitlementsDeleteGroup deletes group, a group already absent is not an error
+func (a OsduApiRequest) EntitlementsDeleteGroup(group string) error {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+
+	group_email := a._group_email(group)
+	delete_url := fmt.Sprintf("%s/groups/%s", a.osduSettings.EntitlementsUrl, url.PathEscape(group_email))
+
+	_, err := a._entitlements_request(ctx, http.MethodDelete, delete_url, nil)
+	if errors.Is(err, ErrEntitlementsNotFound) {
+		a._logger().WarnContext(ctx, fmt.Sprintf("Group %s not found", group_email))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Group %s deleted", group_email))
+	return nil
+}
+
+// EntitlementsAddMember adds member_email to group with role, a member already in the group is not an error
+func (a OsduApiRequest) EntitlementsAddMember(group string, member_email string, role string) error {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+
+	if role != models.EntitlementsRoleOwner && role != models.EntitlementsRoleMember {
+		return fmt.Errorf("unknown role %q, expected %s or %s", role, models.EntitlementsRoleOwner, models.EntitlementsRoleMember)
+	}
+
+	group_email := a._group_email(group)
+	add_url := fmt.Sprintf("%s/groups/%s/members", a.osduSettings.EntitlementsUrl, url.PathEscape(group_email))
+	json_content, err := json.Marshal(models.EntitlementsAddUserRequest{Email: member_email, Role: role})
+	if err != nil {
+		return err
+	}
+
+	_, err = a._entitlements_request(ctx, http.MethodPost, add_url, json_content)
+	if errors.Is(err, ErrEntitlementsConflict) {
+		a._logger().WarnContext(ctx, fmt.Sprintf("Member %s already in group %s", member_email, group_email))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Member %s added to group %s as %s", member_email, group_email, role))
+	return nil
+}
+
+// EntitlementsChangeRole sets the role of member_email in group by removing and adding it again
+func (a OsduApiRequest) EntitlementsChangeRole(group string, member_email string, role string) error {
+	// Remove and add run on the same snapshot, a reload in between must not move the add
+	a = a._current()
+	a.live = nil
+
+	if role != models.EntitlementsRoleOwner && role != models.EntitlementsRoleMember {
+		return fmt.Errorf("unknown role %q, expected %s or %s", role, models.EntitlementsRoleOwner, models.EntitlementsRoleMember)
+	}
+	if err := a.EntitlementsRemoveMember(group, member_email); err != nil {
+		return err
+	}
+	if err := a.EntitlementsAddMember(group, member_email, role); err != nil {
+		return fmt.Errorf("member %s removed from %s but not added back as %s: %w", member_email, group, role, err)
+	}
+	return nil
+}
+
+// EntitlementsUpdateGroup patches the description and app ids of group (PATCH /groups/{email})
+func (a OsduApiRequest) EntitlementsUpdateGroup(group string, update models.EntitlementsGroupUpdate) error {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements.go")
+
+	operations := update.Operations()
+	if len(operations) == 0 {
+		return nil
+	}
+
+	group_email := a._group_email(group)
+	patch_url := fmt.Sprintf("%s/groups/%s", a.osduSettings.EntitlementsUrl, url.PathEscape(group_email))
+	json_content, err := json.Marshal(operations)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a._entitlements_request(ctx, http.MethodPatch, patch_url, json_content); err != nil {
+		return err
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Group %s updated", group_email))
+	return nil
 }
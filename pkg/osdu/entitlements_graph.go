@@ -0,0 +1,139 @@
+package osdu
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+)
+
+// Directions of the entitlements graph crawl
+const (
+	// EntitlementsGraphMembers follows the members of the groups, the default from a group
+	EntitlementsGraphMembers = "members"
+	// EntitlementsGraphGroups follows the groups of the members, the default from a user
+	EntitlementsGraphGroups = "groups"
+)
+
+// EntitlementsGraphOptions configures EntitlementsGraph
+type EntitlementsGraphOptions struct {
+	// Direction is EntitlementsGraphMembers or EntitlementsGraphGroups, chosen from the start when empty
+	Direction string
+	// MaxDepth bounds the levels of nested groups crawled, unlimited when zero
+	MaxDepth int
+}
+
+// EntitlementsAddGroupMember nests member_group in group with role, i.e users.datalake.admins
+// in data.default.owners. Both are group names or emails.
+func (a OsduApiRequest) EntitlementsAddGroupMember(group string, member_group string, role string) error {
+	a = a._current()
+	return a.EntitlementsAddMember(group, a._group_email(member_group), role)
+}
+
+// EntitlementsGraph crawls the memberships from start, a group (name or email) or a user, and
+// returns the graph. Every group is read once, so cycles of nested groups end the crawl.
+//
+// The groups of a member (GET /members/{email}/groups) are its direct and inherited groups, so
+// following the groups reads them once from start and takes the direct memberships from their
+// members. The caller needs the right to list the members of these groups.
+func (a OsduApiRequest) EntitlementsGraph(start string, options EntitlementsGraphOptions) (*models.EntitlementsGraph, error) {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "entitlements_graph.go")
+
+	root, root_type := start, models.EntitlementsMemberTypeUser
+	if !strings.Contains(start, "@") || a._is_group_email(start) {
+		root, root_type = a._group_email(start), models.EntitlementsMemberTypeGroup
+	}
+
+	direction := options.Direction
+	if direction == "" {
+		direction = EntitlementsGraphGroups
+		if root_type == models.EntitlementsMemberTypeGroup {
+			direction = EntitlementsGraphMembers
+		}
+	}
+	if direction != EntitlementsGraphMembers && direction != EntitlementsGraphGroups {
+		return nil, fmt.Errorf("unknown direction %q, expected %s or %s", direction, EntitlementsGraphMembers, EntitlementsGraphGroups)
+	}
+
+	// groups lists the groups of root and members_of their members, following the groups
+	var groups []string
+	members_of := map[string][]models.EntitlementsMember{}
+	if direction == EntitlementsGraphGroups {
+		member_groups, err := a.ListMemberGroups(root, "")
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", root, err)
+		}
+		for _, group := range member_groups.Groups {
+			members, err := a.ListGroupMembers(group.Email, models.EntitlementsListMembersOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("group %s: %w", group.Email, err)
+			}
+			groups = append(groups, group.Email)
+			members_of[strings.ToLower(group.Email)] = members
+		}
+	}
+
+	graph := models.NewEntitlementsGraph(root, root_type)
+	type crawl struct {
+		email string
+		depth int
+	}
+	visited := map[string]bool{strings.ToLower(root): true}
+	queue := []crawl{{email: root}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		var next []string
+		if direction == EntitlementsGraphMembers {
+			members, err := a.ListGroupMembers(current.email, models.EntitlementsListMembersOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("group %s: %w", current.email, err)
+			}
+			for _, member := range members {
+				member_type := models.EntitlementsMemberTypeUser
+				if strings.EqualFold(member.MemberType, models.EntitlementsMemberTypeGroup) || a._is_group_email(member.Email) {
+					member_type = models.EntitlementsMemberTypeGroup
+					next = append(next, member.Email)
+				}
+				graph.AddEdge(member.Email, member_type, current.email, strings.ToUpper(member.Role))
+			}
+		} else {
+			member_type := models.EntitlementsMemberTypeUser
+			if current.email != root || root_type == models.EntitlementsMemberTypeGroup {
+				member_type = models.EntitlementsMemberTypeGroup
+			}
+			for _, group := range groups {
+				for _, member := range members_of[strings.ToLower(group)] {
+					if strings.EqualFold(member.Email, current.email) {
+						graph.AddEdge(current.email, member_type, group, strings.ToUpper(member.Role))
+						next = append(next, group)
+					}
+				}
+			}
+		}
+
+		if options.MaxDepth > 0 && current.depth+1 >= options.MaxDepth {
+			continue
+		}
+		for _, email := range next {
+			if visited[strings.ToLower(email)] {
+				continue
+			}
+			visited[strings.ToLower(email)] = true
+			queue = append(queue, crawl{email: email, depth: current.depth + 1})
+		}
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Entitlements graph from %s: %d nodes, %d cycles",
+		root, len(graph.Nodes()), len(graph.Cycles())))
+	return graph, nil
+}
+
+// _is_group_email reports whether email is a group of the partition
+func (a OsduApiRequest) _is_group_email(email string) bool {
+	suffix := fmt.Sprintf("@%s.%s", a.osduSettings.PartitionId, a.osduSettings.EntitlementsDomain)
+	return strings.HasSuffix(strings.ToLower(email), strings.ToLower(suffix))
+}
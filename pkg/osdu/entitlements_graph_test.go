@@ -0,0 +1,160 @@
+package osdu_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// entitlementsHierarchy nests users.datalake.admins in data.default.owners, and
+// users.datalake.ops in users.datalake.admins which is nested back in data.default.owners
+var entitlementsHierarchy = map[string][]models.EntitlementsMember{
+	"data.default.owners@opendes.group": {
+		{Email: "users.datalake.admins@opendes.group", Role: "OWNER", MemberType: "GROUP"},
+		{Email: "owner@opendes.com", Role: "OWNER", MemberType: "USER"},
+	},
+	"users.datalake.admins@opendes.group": {
+		{Email: "admin@opendes.com", Role: "MEMBER", MemberType: "USER"},
+		// memberType is not always returned, the group domain tells
+		{Email: "users.datalake.ops@opendes.group", Role: "MEMBER"},
+	},
+	"users.datalake.ops@opendes.group": {
+		{Email: "ops@opendes.com", Role: "MEMBER", MemberType: "USER"},
+		{Email: "data.default.owners@opendes.group", Role: "MEMBER", MemberType: "GROUP"},
+	},
+}
+
+func newEntitlementsHierarchyServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		if group, ok := strings.CutPrefix(r.URL.Path, "/groups/"); ok {
+			members, ok := entitlementsHierarchy[strings.TrimSuffix(group, "/members")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(models.EntitlementsMembersResponse{Members: members})
+			return
+		}
+
+		// The groups of a member are its direct and inherited groups
+		member := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/members/"), "/groups")
+		response := models.EntitlementsGroupsResponse{MemberEmail: member, Groups: []models.EntitlementsGroup{}}
+		found := map[string]bool{}
+		for queue := []string{member}; len(queue) > 0; queue = queue[1:] {
+			for group, members := range entitlementsHierarchy {
+				for _, m := range members {
+					if m.Email == queue[0] && !found[group] {
+						found[group] = true
+						response.Groups = append(response.Groups, models.EntitlementsGroup{Email: group})
+						queue = append(queue, group)
+					}
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestMockEntitlementsAddGroupMember(t *testing.T) {
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/groups/data.default.owners@opendes.group/members", r.URL.Path)
+		var body models.EntitlementsAddUserRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, models.EntitlementsAddUserRequest{Email: "users.datalake.admins@opendes.group", Role: "MEMBER"}, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	assert.NoError(t, client.EntitlementsAddGroupMember("data.default.owners", "users.datalake.admins", models.EntitlementsRoleMember))
+}
+
+func TestMockEntitlementsGraphMembers(t *testing.T) {
+	entitlementsServer := newEntitlementsHierarchyServer(t)
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	graph, err := client.EntitlementsGraph("data.default.owners", osdu.EntitlementsGraphOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "data.default.owners@opendes.group", graph.Root)
+	assert.Len(t, graph.Edges(), 6)
+	assert.Equal(t, []models.EntitlementsEffectiveMembership{
+		{Member: "admin@opendes.com", Group: "data.default.owners@opendes.group", Via: []string{"users.datalake.admins@opendes.group"}},
+		{Member: "ops@opendes.com", Group: "data.default.owners@opendes.group",
+			Via: []string{"users.datalake.ops@opendes.group", "users.datalake.admins@opendes.group"}},
+		{Member: "owner@opendes.com", Group: "data.default.owners@opendes.group"},
+	}, graph.EffectiveMembers(graph.Root))
+	assert.Equal(t, [][]string{{
+		"data.default.owners@opendes.group",
+		"users.datalake.ops@opendes.group",
+		"users.datalake.admins@opendes.group",
+	}}, graph.Cycles())
+
+	dot := graph.DOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph entitlements {\n"))
+	assert.Contains(t, dot, `"data.default.owners@opendes.group" [shape=box, style=bold];`)
+	assert.Contains(t, dot, `"owner@opendes.com" [shape=ellipse];`)
+	assert.Contains(t, dot, `"owner@opendes.com" -> "data.default.owners@opendes.group" [label="OWNER"];`)
+	assert.Contains(t, dot, `"users.datalake.ops@opendes.group" -> "users.datalake.admins@opendes.group" [label="MEMBER", color=red];`)
+
+	data, err := graph.JSON()
+	require.NoError(t, err)
+	var report models.EntitlementsGraphReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, graph.Report(), report)
+	assert.Len(t, report.Nodes, 6)
+	assert.Len(t, report.Effective, 3)
+
+	// The crawl stops at the direct members
+	graph, err = client.EntitlementsGraph("data.default.owners@opendes.group", osdu.EntitlementsGraphOptions{MaxDepth: 1})
+	require.NoError(t, err)
+	assert.Len(t, graph.Edges(), 2)
+	assert.Empty(t, graph.Cycles())
+
+	_, err = client.EntitlementsGraph("data.unknown.owners", osdu.EntitlementsGraphOptions{})
+	assert.ErrorIs(t, err, osdu.ErrEntitlementsNotFound)
+}
+
+func TestMockEntitlementsGraphGroups(t *testing.T) {
+	entitlementsServer := newEntitlementsHierarchyServer(t)
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	graph, err := client.EntitlementsGraph("ops@opendes.com", osdu.EntitlementsGraphOptions{})
+	require.NoError(t, err)
+
+	assert.False(t, graph.IsGroup("ops@opendes.com"))
+	assert.Equal(t, []models.EntitlementsEffectiveMembership{
+		{Member: "ops@opendes.com", Group: "data.default.owners@opendes.group",
+			Via: []string{"users.datalake.ops@opendes.group", "users.datalake.admins@opendes.group"}},
+		{Member: "ops@opendes.com", Group: "users.datalake.admins@opendes.group", Via: []string{"users.datalake.ops@opendes.group"}},
+		{Member: "ops@opendes.com", Group: "users.datalake.ops@opendes.group"},
+	}, graph.Report().Effective)
+	assert.Equal(t, []models.EntitlementsGraphEdge{
+		{Member: "users.datalake.admins@opendes.group", Group: "data.default.owners@opendes.group", Role: "OWNER"},
+		{Member: "users.datalake.ops@opendes.group", Group: "users.datalake.admins@opendes.group", Role: "MEMBER"},
+		{Member: "data.default.owners@opendes.group", Group: "users.datalake.ops@opendes.group", Role: "MEMBER"},
+		{Member: "ops@opendes.com", Group: "users.datalake.ops@opendes.group", Role: "MEMBER"},
+	}, graph.Edges())
+	assert.Len(t, graph.Cycles(), 1)
+
+	// The crawl stops at the direct groups
+	graph, err = client.EntitlementsGraph("ops@opendes.com", osdu.EntitlementsGraphOptions{MaxDepth: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []models.EntitlementsGraphEdge{
+		{Member: "ops@opendes.com", Group: "users.datalake.ops@opendes.group", Role: "MEMBER"},
+	}, graph.Edges())
+
+	_, err = client.EntitlementsGraph("ops@opendes.com", osdu.EntitlementsGraphOptions{Direction: "up"})
+	assert.ErrorContains(t, err, `unknown direction "up"`)
+}
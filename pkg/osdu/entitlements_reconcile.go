@@ -0,0 +1,127 @@
+package osdu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+)
+
+// PlanEntitlements reads the live state of the groups of state and returns the actions
+// reconciling them. The caller is always a protected member, removing it would lock the
+// reconciliation out of the groups.
+func (a OsduApiRequest) PlanEntitlements(state models.EntitlementsState, options models.EntitlementsPlanOptions) (models.EntitlementsPlan, error) {
+	// The live state is read on a single snapshot, the plan is for the partition it was read from
+	a = a._current()
+	a.live = nil
+	ctx := context.WithValue(a.Context(), OsduApi, "reconcile_entitlements")
+
+	if err := state.Validate(); err != nil {
+		return models.EntitlementsPlan{}, err
+	}
+	if state.PartitionId != "" && !strings.EqualFold(state.PartitionId, a.osduSettings.PartitionId) {
+		return models.EntitlementsPlan{}, fmt.Errorf("entitlements state is for partition %s, client partition is %s",
+			state.PartitionId, a.osduSettings.PartitionId)
+	}
+
+	mine, err := a.ListMyGroups()
+	if err != nil {
+		return models.EntitlementsPlan{}, err
+	}
+	descriptions := map[string]string{}
+	for _, group := range mine.Groups {
+		descriptions[strings.ToLower(group.Name)] = group.Description
+	}
+
+	var live []models.EntitlementsLiveGroup
+	for _, group := range state.Groups {
+		name := strings.ToLower(group.Name)
+		members, err := a.ListGroupMembers(name, models.EntitlementsListMembersOptions{})
+		if errors.Is(err, ErrEntitlementsNotFound) {
+			continue
+		}
+		if err != nil {
+			return models.EntitlementsPlan{}, err
+		}
+
+		live_group := models.EntitlementsLiveGroup{Name: name, Exists: true, Members: members}
+		if description, ok := descriptions[name]; ok {
+			live_group.Description = &description
+		}
+		live = append(live, live_group)
+	}
+
+	if mine.MemberEmail != "" {
+		options.ProtectedMembers = append(append([]string{}, options.ProtectedMembers...), mine.MemberEmail)
+	}
+	plan := models.PlanEntitlements(state, live, options)
+	plan.PartitionId = a.osduSettings.PartitionId
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Entitlements plan for %s: %d actions", plan.PartitionId, len(plan.Actions)))
+	return plan, nil
+}
+
+// ApplyEntitlementsPlan runs the actions of plan in order. A failed action does not stop the
+// others, except the actions on a group that could not be created. The errors are joined.
+func (a OsduApiRequest) ApplyEntitlementsPlan(plan models.EntitlementsPlan) error {
+	// The actions run on the snapshot the partition was checked on, a reload must not move them
+	a = a._current()
+	a.live = nil
+	ctx := context.WithValue(a.Context(), OsduApi, "reconcile_entitlements")
+
+	if plan.PartitionId != "" && !strings.EqualFold(plan.PartitionId, a.osduSettings.PartitionId) {
+		return fmt.Errorf("entitlements plan is for partition %s, client partition is %s",
+			plan.PartitionId, a.osduSettings.PartitionId)
+	}
+
+	var errs []error
+	failed_groups := map[string]bool{}
+	for _, action := range plan.Actions {
+		if failed_groups[action.Group] {
+			errs = append(errs, fmt.Errorf("%s: skipped, group %s not created", action, action.Group))
+			continue
+		}
+
+		var err error
+		switch action.Kind {
+		case models.EntitlementsActionCreateGroup:
+			err = a._create_group(ctx, action.Group, action.Description)
+			if err != nil {
+				failed_groups[action.Group] = true
+			}
+		case models.EntitlementsActionUpdateGroup:
+			description := action.Description
+			err = a.EntitlementsUpdateGroup(action.Group, models.EntitlementsGroupUpdate{Description: &description})
+		case models.EntitlementsActionAddMember:
+			err = a.EntitlementsAddMember(action.Group, action.Member, action.Role)
+		case models.EntitlementsActionChangeRole:
+			err = a.EntitlementsChangeRole(action.Group, action.Member, action.Role)
+		case models.EntitlementsActionRemoveMember:
+			err = a.EntitlementsRemoveMember(action.Group, action.Member)
+		default:
+			err = fmt.Errorf("unknown action %s", action.Kind)
+		}
+
+		if err != nil {
+			a._logger().ErrorContext(ctx, fmt.Sprintf("%s: %s", action, err))
+			errs = append(errs, fmt.Errorf("%s: %w", action, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReconcileEntitlements plans the state and applies the plan, the plan is returned even when
+// applying it failed
+func (a OsduApiRequest) ReconcileEntitlements(state models.EntitlementsState, options models.EntitlementsPlanOptions) (models.EntitlementsPlan, error) {
+	// Plan and apply on the same snapshot
+	a = a._current()
+	a.live = nil
+
+	plan, err := a.PlanEntitlements(state, options)
+	if err != nil {
+		return plan, err
+	}
+	return plan, a.ApplyEntitlementsPlan(plan)
+}
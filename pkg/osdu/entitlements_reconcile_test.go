@@ -0,0 +1,288 @@
+package osdu_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEntitlements keeps the groups of the opendes partition, the caller is datafier@service.local
+type fakeEntitlements struct {
+	mu           sync.Mutex
+	descriptions map[string]string
+	members      map[string]map[string]string
+	requests     []string
+}
+
+func newFakeEntitlements() *fakeEntitlements {
+	return &fakeEntitlements{
+		descriptions: map[string]string{
+			"users":                 "Datalake users",
+			"data.default.viewers":  "Viewers",
+			"users.datalake.admins": "Datalake admins",
+		},
+		members: map[string]map[string]string{
+			"users": {
+				"datafier@service.local": "OWNER",
+				"former@opendes.com":     "MEMBER",
+			},
+			"data.default.viewers": {
+				"datafier@service.local": "OWNER",
+				"former@opendes.com":     "MEMBER",
+				"ops@opendes.com":        "MEMBER",
+			},
+			"users.datalake.admins": {
+				"datafier@service.local": "OWNER",
+			},
+		},
+	}
+}
+
+func (f *fakeEntitlements) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method != http.MethodGet {
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	}
+
+	group_email, member, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/groups/"), "/members")
+	group := strings.TrimSuffix(group_email, "@opendes.group")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/groups":
+		var groups []models.EntitlementsGroup
+		for name, description := range f.descriptions {
+			groups = append(groups, models.EntitlementsGroup{Name: name, Description: description, Email: name + "@opendes.group"})
+		}
+		json.NewEncoder(w).Encode(models.EntitlementsGroupsResponse{MemberEmail: "datafier@service.local", Groups: groups})
+	case r.Method == http.MethodPost && r.URL.Path == "/groups":
+		var body models.EntitlementsCreateGroupRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := f.members[body.GroupName]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.descriptions[body.GroupName] = body.Description
+		f.members[body.GroupName] = map[string]string{"datafier@service.local": "OWNER"}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		members, ok := f.members[group]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			response := models.EntitlementsMembersResponse{Members: []models.EntitlementsMember{}}
+			for email, role := range members {
+				response.Members = append(response.Members, models.EntitlementsMember{Email: email, Role: role})
+			}
+			json.NewEncoder(w).Encode(response)
+		case http.MethodPost:
+			var body models.EntitlementsAddUserRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			if _, ok := members[body.Email]; ok {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			members[body.Email] = body.Role
+		case http.MethodDelete:
+			email := strings.TrimPrefix(member, "/")
+			if _, ok := members[email]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(members, email)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodPatch:
+			var operations []models.EntitlementsGroupPatchOperation
+			json.NewDecoder(r.Body).Decode(&operations)
+			for _, operation := range operations {
+				if operation.Path == "/description" {
+					f.descriptions[group] = operation.Value[0]
+				}
+			}
+		}
+	}
+}
+
+const entitlementsStateYaml = `
+partitionId: opendes
+groups:
+  - name: users
+    members:
+      - email: datafier@service.local
+        role: OWNER
+  - name: data.default.viewers
+    description: Default data viewers
+    members:
+      - email: ops@opendes.com
+        role: owner
+      - email: admin@opendes.com
+  - name: data.tenant.viewers
+    description: Tenant viewers
+    members:
+      - email: Admin@opendes.com
+        role: OWNER
+`
+
+func TestEntitlementsStateValidate(t *testing.T) {
+	_, err := models.ParseEntitlementsState([]byte(`
+groups:
+  - name: data.default.viewers
+    members:
+      - email: admin@opendes.com
+        role: ADMIN
+      - email: ADMIN@opendes.com
+  - name: data.default.viewers@opendes.group
+  - name: ""
+  - name: Data.Default.Viewers
+`))
+	assert.ErrorContains(t, err, `groups[0].members[0]: unknown role "ADMIN"`)
+	assert.ErrorContains(t, err, "groups[0].members[1]: duplicated member ADMIN@opendes.com")
+	assert.ErrorContains(t, err, "groups[1]: name data.default.viewers@opendes.group must not include the partition domain")
+	assert.ErrorContains(t, err, "groups[2]: name cannot be empty")
+	assert.ErrorContains(t, err, "groups[3]: duplicated group Data.Default.Viewers")
+}
+
+func TestMockPlanEntitlements(t *testing.T) {
+	fake := newFakeEntitlements()
+	entitlementsServer := httptest.NewServer(fake)
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	state, err := models.ParseEntitlementsState([]byte(entitlementsStateYaml))
+	require.NoError(t, err)
+
+	plan, err := client.PlanEntitlements(state, models.EntitlementsPlanOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.EntitlementsAction{
+		{Kind: models.EntitlementsActionCreateGroup, Group: "data.tenant.viewers", Description: "Tenant viewers"},
+		{Kind: models.EntitlementsActionUpdateGroup, Group: "data.default.viewers", Description: "Default data viewers"},
+		{Kind: models.EntitlementsActionAddMember, Group: "data.default.viewers", Member: "admin@opendes.com", Role: "MEMBER"},
+		{Kind: models.EntitlementsActionAddMember, Group: "data.tenant.viewers", Member: "admin@opendes.com", Role: "OWNER"},
+		{Kind: models.EntitlementsActionChangeRole, Group: "data.default.viewers", Member: "ops@opendes.com", Role: "OWNER", FromRole: "MEMBER"},
+	}, plan.Actions)
+	assert.Empty(t, plan.Protected)
+	assert.Empty(t, fake.requests, "planning is read only")
+
+	// Prune removes the members missing from the state, except in protected groups and the caller
+	plan, err = client.PlanEntitlements(state, models.EntitlementsPlanOptions{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.Count(models.EntitlementsActionRemoveMember))
+	assert.Equal(t, models.EntitlementsAction{
+		Kind: models.EntitlementsActionRemoveMember, Group: "data.default.viewers", Member: "former@opendes.com", FromRole: "MEMBER",
+	}, plan.Actions[len(plan.Actions)-1])
+	assert.ElementsMatch(t, []models.EntitlementsAction{
+		{Kind: models.EntitlementsActionRemoveMember, Group: "users", Member: "former@opendes.com", FromRole: "MEMBER"},
+		{Kind: models.EntitlementsActionRemoveMember, Group: "data.default.viewers", Member: "datafier@service.local", FromRole: "OWNER"},
+	}, plan.Protected)
+
+	text := plan.String()
+	assert.Contains(t, text, "entitlements opendes: 1 groups to create, 1 to update, 2 members to add, 1 role changes, 1 members to remove\n")
+	assert.Contains(t, text, "~ member ops@opendes.com in data.default.viewers: MEMBER -> OWNER\n")
+	assert.Contains(t, text, "- member former@opendes.com from data.default.viewers\n")
+	assert.Contains(t, text, "= member former@opendes.com kept in users (protected)\n")
+
+	// The state of another partition is refused
+	state.PartitionId = "tenant-a"
+	_, err = client.PlanEntitlements(state, models.EntitlementsPlanOptions{})
+	assert.ErrorContains(t, err, "entitlements state is for partition tenant-a, client partition is opendes")
+}
+
+func TestMockReconcileEntitlements(t *testing.T) {
+	fake := newFakeEntitlements()
+	entitlementsServer := httptest.NewServer(fake)
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	state, err := models.ParseEntitlementsState([]byte(entitlementsStateYaml))
+	require.NoError(t, err)
+
+	plan, err := client.ReconcileEntitlements(state, models.EntitlementsPlanOptions{Prune: true})
+	require.NoError(t, err)
+	assert.Len(t, plan.Actions, 6)
+
+	assert.Equal(t, "Default data viewers", fake.descriptions["data.default.viewers"])
+	assert.Equal(t, "Tenant viewers", fake.descriptions["data.tenant.viewers"])
+	assert.Equal(t, map[string]string{
+		"datafier@service.local": "OWNER",
+		"ops@opendes.com":        "OWNER",
+		"admin@opendes.com":      "MEMBER",
+	}, fake.members["data.default.viewers"])
+	assert.Equal(t, map[string]string{
+		"datafier@service.local": "OWNER",
+		"admin@opendes.com":      "OWNER",
+	}, fake.members["data.tenant.viewers"])
+	assert.Contains(t, fake.members["users"], "former@opendes.com")
+
+	// Reconciled
+	fake.requests = nil
+	plan, err = client.ReconcileEntitlements(state, models.EntitlementsPlanOptions{Prune: true})
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+	assert.Empty(t, fake.requests)
+}
+
+func TestMockReconcileEntitlementsProtectedRoleChange(t *testing.T) {
+	fake := newFakeEntitlements()
+	entitlementsServer := httptest.NewServer(fake)
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	state, err := models.ParseEntitlementsState([]byte(`
+partitionId: opendes
+groups:
+  - name: users.datalake.admins
+    members:
+      - email: datafier@service.local
+        role: MEMBER
+`))
+	require.NoError(t, err)
+
+	// The caller keeps its role, a change would remove it from the group before adding it back
+	plan, err := client.ReconcileEntitlements(state, models.EntitlementsPlanOptions{})
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+	assert.Equal(t, []models.EntitlementsAction{
+		{Kind: models.EntitlementsActionChangeRole, Group: "users.datalake.admins", Member: "datafier@service.local", Role: "MEMBER", FromRole: "OWNER"},
+	}, plan.Protected)
+	assert.Contains(t, plan.String(), "= member datafier@service.local kept in users.datalake.admins as OWNER (protected)\n")
+	assert.Empty(t, fake.requests)
+	assert.Equal(t, "OWNER", fake.members["users.datalake.admins"]["datafier@service.local"])
+}
+
+func TestMockApplyEntitlementsPlanGroupFailure(t *testing.T) {
+	var requests []string
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/groups" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	err := client.ApplyEntitlementsPlan(models.EntitlementsPlan{
+		PartitionId: "opendes",
+		Actions: []models.EntitlementsAction{
+			{Kind: models.EntitlementsActionCreateGroup, Group: "data.tenant.viewers"},
+			{Kind: models.EntitlementsActionAddMember, Group: "data.tenant.viewers", Member: "admin@opendes.com", Role: "OWNER"},
+			{Kind: models.EntitlementsActionAddMember, Group: "data.default.viewers", Member: "admin@opendes.com", Role: "MEMBER"},
+		},
+	})
+	assert.ErrorContains(t, err, "+ group data.tenant.viewers: entitlements service response - 403")
+	assert.ErrorContains(t, err, "+ member admin@opendes.com to data.tenant.viewers as OWNER: skipped, group data.tenant.viewers not created")
+	assert.Equal(t, []string{
+		"POST /groups",
+		"POST /groups/data.default.viewers@opendes.group/members",
+	}, requests)
+}
@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/heba920908/osdu-sdk-go/pkg/auth"
 	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
 	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMockEntitlementsBootstrap(t *testing.T) {
@@ -77,6 +81,119 @@ func TestMockEntitlementsBootstrap(t *testing.T) {
 	}
 }
 
+func TestResolveEntitlementsBootstrapUsers(t *testing.T) {
+	bootstrapFile := filepath.Join(t.TempDir(), "bootstrap.yaml")
+	require.NoError(t, os.WriteFile(bootstrapFile, []byte(`aliasMappings:
+  - aliasId: SERVICE_PRINCIPAL_AIRFLOW
+    userId: 0b2d0c3e-5c6a-4f3e-9d4e-1a2b3c4d5e6f
+  - aliasId: SERVICE_PRINCIPAL
+    userId: 0b2d0c3e-5c6a-4f3e-9d4e-1a2b3c4d5e6f
+`), 0600))
+
+	// Defaults, sorted
+	users, err := models.ResolveEntitlementsBootstrapUsers(false, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultEntitlementsBootstrapUsers(), users)
+	assert.Equal(t, models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL", UserId: "datafier@service.local"}, users[0])
+
+	// The file replaces the airflow principal and adds a service principal, the inline mappings come last
+	users, err = models.ResolveEntitlementsBootstrapUsers(false, bootstrapFile, []models.EntitlementsBoostrapUser{
+		{AliasId: "SERVICE_PRINCIPAL_AIRFLOW", UserId: "airflow@opendes.com"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, users, 10)
+	assert.Contains(t, users, models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL", UserId: "0b2d0c3e-5c6a-4f3e-9d4e-1a2b3c4d5e6f"})
+	assert.Contains(t, users, models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL_AIRFLOW", UserId: "airflow@opendes.com"})
+	assert.NotContains(t, users, models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL_AIRFLOW", UserId: "airflow@service.local"})
+
+	// Replace keeps the file mappings only
+	users, err = models.ResolveEntitlementsBootstrapUsers(true, bootstrapFile, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []models.EntitlementsBoostrapUser{
+		{AliasId: "SERVICE_PRINCIPAL", UserId: "0b2d0c3e-5c6a-4f3e-9d4e-1a2b3c4d5e6f"},
+		{AliasId: "SERVICE_PRINCIPAL_AIRFLOW", UserId: "0b2d0c3e-5c6a-4f3e-9d4e-1a2b3c4d5e6f"},
+	}, users)
+
+	require.NoError(t, os.WriteFile(bootstrapFile, []byte(`aliasMappings:
+  - aliasId: SERVICE_PRINCIPAL_STORAGE
+    userId: storage
+  - aliasId: SERVICE_PRINCIPAL_INDEXER
+    userId: indexer@opendes.com
+  - aliasId: SERVICE_PRINCIPAL_INDEXER
+    userId: indexer-2@opendes.com
+`), 0600))
+	_, err = models.ResolveEntitlementsBootstrapUsers(false, bootstrapFile, nil)
+	assert.ErrorContains(t, err, `aliasMappings[0]: malformed user id "storage"`)
+	assert.ErrorContains(t, err, "aliasMappings[2]: alias SERVICE_PRINCIPAL_INDEXER is already mapped to indexer@opendes.com")
+}
+
+func TestMockEntitlementsBootstrapMappings(t *testing.T) {
+	var bodies []models.EntitlementsBootstrapRequest
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body models.EntitlementsBootstrapRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	// The default mappings are sent in the same order every time
+	assert.NoError(t, client.EntitlementsBootstrap())
+	assert.NoError(t, client.EntitlementsBootstrap())
+	assert.Equal(t, models.DefaultEntitlementsBootstrapUsers(), bodies[0].AliasMappings)
+	assert.Equal(t, bodies[0], bodies[1])
+
+	assert.NoError(t, client.EntitlementsBootstrap(
+		models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL_STORAGE", UserId: "storage@opendes.com"},
+		models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL", UserId: "datafier@opendes.com"},
+	))
+	assert.Equal(t, []models.EntitlementsBoostrapUser{
+		{AliasId: "SERVICE_PRINCIPAL", UserId: "datafier@opendes.com"},
+		{AliasId: "SERVICE_PRINCIPAL_STORAGE", UserId: "storage@opendes.com"},
+	}, bodies[2].AliasMappings)
+
+	// Invalid mappings are not sent
+	err := client.EntitlementsBootstrap(models.EntitlementsBoostrapUser{AliasId: "SERVICE_PRINCIPAL_STORAGE", UserId: "storage"})
+	assert.ErrorContains(t, err, `malformed user id "storage"`)
+	assert.Len(t, bodies, 3)
+}
+
+func TestMockEntitlementsBootstrapSettings(t *testing.T) {
+	var body models.EntitlementsBootstrapRequest
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer entitlementsServer.Close()
+
+	_, mockAuth := createMockEntitlementsClient(entitlementsServer.URL)
+	client, err := osdu.New(
+		osdu.WithAuthProvider(mockAuth),
+		osdu.WithSettings(config.OsduSettings{
+			PartitionId:        "opendes",
+			EntitlementsUrl:    entitlementsServer.URL,
+			EntitlementsDomain: "group",
+			EntitlementsBootstrap: config.EntitlementsBootstrapSettings{
+				Replace: true,
+				AliasMappings: []config.EntitlementsAliasMapping{
+					{AliasId: "SERVICE_PRINCIPAL_STORAGE", UserId: "storage@opendes.com"},
+					{AliasId: "SERVICE_PRINCIPAL", UserId: "datafier@opendes.com"},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	// The mappings of the settings are sent when none is given
+	require.NoError(t, client.EntitlementsBootstrap())
+	assert.Equal(t, []models.EntitlementsBoostrapUser{
+		{AliasId: "SERVICE_PRINCIPAL", UserId: "datafier@opendes.com"},
+		{AliasId: "SERVICE_PRINCIPAL_STORAGE", UserId: "storage@opendes.com"},
+	}, body.AliasMappings)
+}
+
 func TestMockCreateEntitlementsAdminUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -265,3 +382,213 @@ func createMockEntitlementsClient(entitlementsURL string) (osdu.OsduApiRequest,
 	client := osdu.NewClientWithConfig(mockAuth, osduSettings)
 	return client, mockAuth
 }
+
+// createReadEntitlementsClient creates a client with an entitlements domain and no retry delay
+func createReadEntitlementsClient(t *testing.T, entitlementsURL string) osdu.OsduApiRequest {
+	_, mockAuth := createMockEntitlementsClient(entitlementsURL)
+	client, err := osdu.New(
+		osdu.WithAuthProvider(mockAuth),
+		osdu.WithSettings(config.OsduSettings{
+			PartitionId:        "opendes",
+			EntitlementsUrl:    entitlementsURL,
+			EntitlementsDomain: "group",
+		}),
+		osdu.WithRetryPolicy(osdu.RetryPolicy{Attempts: 2}),
+	)
+	assert.NoError(t, err)
+	return client
+}
+
+func TestMockEntitlementsListMyGroups(t *testing.T) {
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/groups", r.URL.Path)
+		assert.Equal(t, "opendes", r.Header.Get("data-partition-id"))
+		w.Write([]byte(`{
+			"desId": "datafier@service.local",
+			"memberEmail": "datafier@service.local",
+			"groups": [
+				{"name": "users", "description": "Datalake users", "email": "users@opendes.group"},
+				{"name": "users.datalake.admins", "description": "Datalake admins", "email": "users.datalake.admins@opendes.group"}
+			]
+		}`))
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	groups, err := client.ListMyGroups()
+	assert.NoError(t, err)
+	assert.Equal(t, "datafier@service.local", groups.MemberEmail)
+	assert.Equal(t, []models.EntitlementsGroup{
+		{Name: "users", Description: "Datalake users", Email: "users@opendes.group"},
+		{Name: "users.datalake.admins", Description: "Datalake admins", Email: "users.datalake.admins@opendes.group"},
+	}, groups.Groups)
+}
+
+func TestMockEntitlementsListGroupMembers(t *testing.T) {
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/groups/users.datalake.admins@opendes.group/members", r.URL.Path)
+		assert.Equal(t, "OWNER", r.URL.Query().Get("role"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"members": [
+				{"email": "datafier@service.local", "role": "OWNER"},
+				{"email": "admin@opendes.com", "role": "OWNER"}
+			], "cursor": "page-2"}`))
+		case "page-2":
+			w.Write([]byte(`{"members": [{"email": "users.datalake.ops@opendes.group", "role": "OWNER", "memberType": "GROUP"}]}`))
+		default:
+			t.Errorf("unexpected cursor %s", r.URL.Query().Get("cursor"))
+		}
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+	options := models.EntitlementsListMembersOptions{Role: models.EntitlementsRoleOwner, Limit: 2}
+
+	page, err := client.ListGroupMembersPage("users.datalake.admins", options)
+	assert.NoError(t, err)
+	assert.Len(t, page.Members, 2)
+	assert.Equal(t, "page-2", page.Cursor)
+
+	members, err := client.ListGroupMembers("users.datalake.admins@opendes.group", options)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.EntitlementsMember{
+		{Email: "datafier@service.local", Role: "OWNER"},
+		{Email: "admin@opendes.com", Role: "OWNER"},
+		{Email: "users.datalake.ops@opendes.group", Role: "OWNER", MemberType: "GROUP"},
+	}, members)
+}
+
+func TestMockEntitlementsListMemberGroups(t *testing.T) {
+	requests := 0
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/members/unknown@opendes.com/groups" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "/members/admin@opendes.com/groups", r.URL.Path)
+		assert.Equal(t, r.URL.Query().Get("type"), "DATA")
+		w.Write([]byte(`{"memberEmail": "admin@opendes.com", "groups": [
+			{"name": "data.default.owners", "email": "data.default.owners@opendes.group"}
+		]}`))
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	groups, err := client.ListMemberGroups("admin@opendes.com", models.EntitlementsGroupTypeData)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@opendes.com", groups.MemberEmail)
+	assert.Equal(t, "data.default.owners@opendes.group", groups.Groups[0].Email)
+
+	// Not found is not retried
+	requests = 0
+	_, err = client.ListMemberGroups("unknown@opendes.com", "")
+	assert.ErrorIs(t, err, osdu.ErrEntitlementsNotFound)
+	assert.Equal(t, 1, requests)
+}
+
+func TestMockEntitlementsRemoveMemberAndDeleteGroup(t *testing.T) {
+	var requests []string
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/groups/users.datalake.viewers@opendes.group/members/admin@opendes.com",
+			"/groups/data.legacy.viewers@opendes.group":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	assert.NoError(t, client.EntitlementsRemoveMember("users.datalake.viewers", "admin@opendes.com"))
+	assert.NoError(t, client.EntitlementsDeleteGroup("data.legacy.viewers@opendes.group"))
+
+	// Already removed
+	assert.NoError(t, client.EntitlementsRemoveMember("users.datalake.viewers", "unknown@opendes.com"))
+	assert.NoError(t, client.EntitlementsDeleteGroup("data.unknown.viewers"))
+	assert.Len(t, requests, 4)
+}
+
+func TestMockEntitlementsChangeRole(t *testing.T) {
+	var requests []string
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			var body models.EntitlementsAddUserRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, models.EntitlementsAddUserRequest{Email: "admin@opendes.com", Role: "OWNER"}, body)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	assert.NoError(t, client.EntitlementsChangeRole("users.datalake.ops", "admin@opendes.com", models.EntitlementsRoleOwner))
+	assert.Equal(t, []string{
+		"DELETE /groups/users.datalake.ops@opendes.group/members/admin@opendes.com",
+		"POST /groups/users.datalake.ops@opendes.group/members",
+	}, requests)
+
+	requests = nil
+	assert.Error(t, client.EntitlementsChangeRole("users.datalake.ops", "admin@opendes.com", "ADMIN"))
+	assert.Empty(t, requests)
+}
+
+func TestMockEntitlementsChangeRoleAddFailure(t *testing.T) {
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	err := client.EntitlementsChangeRole("users.datalake.ops", "admin@opendes.com", models.EntitlementsRoleMember)
+	assert.ErrorContains(t, err, "removed from users.datalake.ops but not added back as MEMBER")
+}
+
+func TestMockEntitlementsUpdateGroup(t *testing.T) {
+	requests := 0
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/groups/data.default.viewers@opendes.group", r.URL.Path)
+
+		var operations []models.EntitlementsGroupPatchOperation
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&operations))
+		assert.Equal(t, []models.EntitlementsGroupPatchOperation{
+			{Op: "replace", Path: "/description", Value: []string{"Default data viewers"}},
+			{Op: "replace", Path: "/appIds", Value: []string{"app-1", "app-2"}},
+		}, operations)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer entitlementsServer.Close()
+
+	client := createReadEntitlementsClient(t, entitlementsServer.URL)
+
+	description := "Default data viewers"
+	assert.NoError(t, client.EntitlementsUpdateGroup("data.default.viewers", models.EntitlementsGroupUpdate{
+		Description: &description,
+		AppIds:      []string{"app-1", "app-2"},
+	}))
+
+	// Nothing to update
+	assert.NoError(t, client.EntitlementsUpdateGroup("data.default.viewers", models.EntitlementsGroupUpdate{}))
+	assert.Equal(t, 1, requests)
+}
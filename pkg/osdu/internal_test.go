@@ -0,0 +1,106 @@
+package osdu_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInternalService_SkipTokenForPartition(t *testing.T) {
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Equal(t, "datafier@service.local", r.Header.Get("x-user-id"))
+		assert.Equal(t, "osdu-sdk-go", r.Header.Get("x-app-id"))
+		assert.Equal(t, "blue", r.Header.Get("x-deployment"))
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer partitionServer.Close()
+
+	// No expectations: the token must not be requested
+	mockAuth := &MockAuthProvider{}
+
+	client := osdu.NewClientWithConfig(mockAuth, config.OsduSettings{
+		PartitionId:  "test-partition",
+		PartitionUrl: "http://unused-external-partition",
+	}).WithInternalService(config.InternalSettings{
+		UserId:  "datafier@service.local",
+		AppId:   "osdu-sdk-go",
+		Headers: map[string]string{"x-deployment": "blue"},
+		Services: map[string]config.InternalServiceSettings{
+			config.ServicePartition: {SkipToken: true, Url: partitionServer.URL},
+		},
+	})
+
+	partition := models.Partition{
+		Properties: models.GetDefaultPartitionPropertiesCI("internal-partition"),
+	}
+
+	err := client.RegisterPartition(partition)
+	assert.NoError(t, err)
+	mockAuth.AssertNotCalled(t, "GetAccessToken", mock.Anything)
+}
+
+func TestInternalService_TokenStillSentWhenNotSkipped(t *testing.T) {
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer mock-access-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "datafier@service.local", r.Header.Get("x-user-id"))
+		assert.Equal(t, "test-partition", r.Header.Get("data-partition-id"))
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer entitlementsServer.Close()
+
+	mockAuth := &MockAuthProvider{}
+	mockAuth.On("GetAccessToken", mock.Anything).Return(&auth.Token{
+		AccessToken: "mock-access-token",
+		TokenType:   "Bearer",
+	}, nil)
+
+	client := osdu.NewClientWithConfig(mockAuth, config.OsduSettings{
+		PartitionId:     "test-partition",
+		EntitlementsUrl: entitlementsServer.URL,
+	}).WithInternalService(config.InternalSettings{
+		UserId: "datafier@service.local",
+		Services: map[string]config.InternalServiceSettings{
+			config.ServicePartition: {SkipToken: true},
+		},
+	})
+
+	err := client.EntitlementsBootstrap()
+	assert.NoError(t, err)
+	mockAuth.AssertExpectations(t)
+}
+
+func TestInternalSettings_ServiceUrl(t *testing.T) {
+	internal := config.InternalSettings{
+		Namespace: "osdu",
+		Services: map[string]config.InternalServiceSettings{
+			config.ServiceWorkflow: {Url: "http://airflow-workflow:8080/api/workflow/v1"},
+		},
+	}
+
+	assert.Equal(t, "http://partition.osdu.svc.cluster.local/api/partition/v1", internal.ServiceUrl(config.ServicePartition))
+	assert.Equal(t, "http://airflow-workflow:8080/api/workflow/v1", internal.ServiceUrl(config.ServiceWorkflow))
+	assert.Equal(t, "", internal.ServiceUrl("unknown"))
+
+	internal.ClusterDomain = "k8s.internal"
+	settings := internal.ResolveUrls(config.OsduSettings{
+		EntitlementsUrl: "https://osdu/api/entitlements/v2",
+	})
+	assert.Equal(t, "http://entitlements.osdu.svc.k8s.internal/api/entitlements/v2", settings.EntitlementsUrl)
+
+	// Without namespace the configured URLs are kept
+	settings = config.InternalSettings{}.ResolveUrls(config.OsduSettings{
+		EntitlementsUrl: "https://osdu/api/entitlements/v2",
+	})
+	assert.Equal(t, "https://osdu/api/entitlements/v2", settings.EntitlementsUrl)
+}
@@ -0,0 +1,222 @@
+package osdu
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	retry "github.com/avast/retry-go"
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+)
+
+// RetryPolicy overrides the attempts and delay of every retried OSDU call
+type RetryPolicy struct {
+	Attempts uint
+	Delay    time.Duration
+}
+
+// Option configures a client created with New
+type Option func(*clientOptions) error
+
+type clientOptions struct {
+	settings     *config.OsduSettings
+	authProvider auth.AuthProvider
+	httpClient   *http.Client
+	logger       *slog.Logger
+	retryPolicy  *RetryPolicy
+	partitionId  string
+	userAgent    string
+	configFile   string
+	profile      string
+}
+
+// WithSettings sets the service URLs and partition of the client
+func WithSettings(settings config.OsduSettings) Option {
+	return func(o *clientOptions) error {
+		o.settings = &settings
+		return nil
+	}
+}
+
+// WithAuthProvider sets the provider used to get access tokens
+func WithAuthProvider(provider auth.AuthProvider) Option {
+	return func(o *clientOptions) error {
+		if provider == nil {
+			return errors.New("auth provider cannot be nil")
+		}
+		o.authProvider = provider
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for every request.
+// Without it a default client skipping TLS verification is used, as NewClient does.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) error {
+		if client == nil {
+			return errors.New("http client cannot be nil")
+		}
+		o.httpClient = client
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the client, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *clientOptions) error {
+		if logger == nil {
+			return errors.New("logger cannot be nil")
+		}
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithRetryPolicy overrides the attempts and delay of retried calls
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *clientOptions) error {
+		if policy.Attempts == 0 {
+			return errors.New("retry policy needs at least one attempt")
+		}
+		o.retryPolicy = &policy
+		return nil
+	}
+}
+
+// WithPartition sets the data-partition-id, overriding the partition of the settings
+func WithPartition(partitionId string) Option {
+	return func(o *clientOptions) error {
+		if len(partitionId) < 2 {
+			return fmt.Errorf("invalid partition id %q", partitionId)
+		}
+		o.partitionId = partitionId
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+func WithUserAgent(userAgent string) Option {
+	return func(o *clientOptions) error {
+		o.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithConfigFile loads the settings and the auth provider from a configuration file.
+// profile selects a profile of a profiles file, empty for OSDU_PROFILE or the current profile.
+// WithSettings and WithAuthProvider take precedence over the file.
+func WithConfigFile(configFile string, profile string) Option {
+	return func(o *clientOptions) error {
+		o.configFile = configFile
+		o.profile = profile
+		return nil
+	}
+}
+
+// New creates a client from options only. Unlike NewClient, it never reads CONFIG_FILE
+// or the environment; the disk is only read when WithConfigFile is given.
+func New(opts ...Option) (OsduApiRequest, error) {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return OsduApiRequest{}, err
+		}
+	}
+
+	var settings config.OsduSettings
+	var internal *config.InternalSettings
+
+	if o.configFile != "" {
+		cfg, err := config.GetConfigProfile(o.configFile, o.profile)
+		if err != nil {
+			return OsduApiRequest{}, err
+		}
+		if o.settings != nil {
+			cfg.OsduClient.OsduSettings = *o.settings
+		}
+		if o.partitionId != "" {
+			cfg.OsduClient.PartitionId = o.partitionId
+		}
+		settings = cfg.OsduClient.OsduSettings
+		if cfg.OsduClient.InternalService {
+			internal = &cfg.OsduClient.Internal
+		}
+
+		// The provider comes from the file, its auth settings must be complete
+		if o.authProvider == nil {
+			if err := cfg.Validate(); err != nil {
+				return OsduApiRequest{}, err
+			}
+			o.authProvider, err = auth.NewProviderFactory().GetProviderFromConfig(cfg.OsduClient)
+			if err != nil {
+				return OsduApiRequest{}, fmt.Errorf("failed to create auth provider: %w", err)
+			}
+		}
+	}
+
+	if o.settings != nil {
+		settings = *o.settings
+	}
+	if o.partitionId != "" {
+		settings.PartitionId = o.partitionId
+	}
+	if o.authProvider == nil {
+		return OsduApiRequest{}, errors.New("an auth provider is required, use WithAuthProvider or WithConfigFile")
+	}
+
+	client := OsduApiRequest{
+		authProvider: o.authProvider,
+		osduSettings: settings,
+		httpClient:   o.httpClient,
+		logger:       o.logger,
+		retryPolicy:  o.retryPolicy,
+		userAgent:    o.userAgent,
+	}
+	if internal != nil {
+		client = client.WithInternalService(*internal)
+	}
+	return client, nil
+}
+
+// insecureDefaultTransport makes the default transport skip TLS verification, once as
+// concurrent requests share it
+var insecureDefaultTransport sync.Once
+
+// _http_client returns the configured HTTP client, or a default one skipping TLS verification
+func (a OsduApiRequest) _http_client() *http.Client {
+	if a.httpClient != nil {
+		return a.httpClient
+	}
+	insecureDefaultTransport.Do(func() {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	})
+	return &http.Client{}
+}
+
+func (a OsduApiRequest) _logger() *slog.Logger {
+	if a.logger != nil {
+		return a.logger
+	}
+	return slog.Default()
+}
+
+// _retry_attempts returns the attempts of the retry policy, def without policy
+func (a OsduApiRequest) _retry_attempts(def uint) retry.Option {
+	if a.retryPolicy != nil {
+		return retry.Attempts(a.retryPolicy.Attempts)
+	}
+	return retry.Attempts(def)
+}
+
+// _retry_delay returns the delay of the retry policy, def without policy
+func (a OsduApiRequest) _retry_delay(def time.Duration) retry.Option {
+	if a.retryPolicy != nil {
+		return retry.Delay(a.retryPolicy.Delay)
+	}
+	return retry.Delay(def)
+}
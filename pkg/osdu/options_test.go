@@ -0,0 +1,105 @@
+package osdu_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransport counts the requests going through a custom HTTP client
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNew_Options(t *testing.T) {
+	var calls atomic.Int32
+	entitlementsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, "osdu-provisioner/1.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "tenant-a", r.Header.Get("data-partition-id"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer entitlementsServer.Close()
+
+	mockAuth := &MockAuthProvider{}
+	mockAuth.On("GetAccessToken", mock.Anything).Return(&auth.Token{AccessToken: "mock-access-token"}, nil)
+
+	var logs bytes.Buffer
+	transport := &countingTransport{}
+
+	client, err := osdu.New(
+		osdu.WithAuthProvider(mockAuth),
+		osdu.WithSettings(config.OsduSettings{
+			PartitionId:     "opendes",
+			EntitlementsUrl: entitlementsServer.URL,
+		}),
+		osdu.WithPartition("tenant-a"),
+		osdu.WithHTTPClient(&http.Client{Transport: transport}),
+		osdu.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		osdu.WithRetryPolicy(osdu.RetryPolicy{Attempts: 2, Delay: time.Millisecond}),
+		osdu.WithUserAgent("osdu-provisioner/1.0"),
+	)
+	require.NoError(t, err)
+
+	err = client.EntitlementsBootstrap()
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int32(2), transport.requests.Load())
+	assert.Contains(t, logs.String(), "Entitlements Boostrap Code: 500")
+}
+
+func TestNew_RequiresAuthProvider(t *testing.T) {
+	_, err := osdu.New(osdu.WithSettings(config.OsduSettings{PartitionId: "opendes"}))
+	assert.ErrorContains(t, err, "auth provider is required")
+
+	_, err = osdu.New(osdu.WithPartition("x"))
+	assert.ErrorContains(t, err, "invalid partition id")
+}
+
+func TestNew_WithConfigFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+current: dev
+profiles:
+  dev:
+    osdu:
+      provider: openid
+      auth:
+        clientId: datafier
+        tokenUrl: https://keycloak/realms/osdu/protocol/openid-connect/token
+        grantType: client_credentials
+      client:
+        partitionId: opendes
+        partitionUrl: https://osdu/api/partition/v1
+        entitlementsUrl: https://osdu/api/entitlements/v2
+`), 0600))
+	t.Setenv(config.ProfileEnv, "")
+
+	client, err := osdu.New(osdu.WithConfigFile(configFile, "dev"))
+	require.NoError(t, err)
+	assert.NotNil(t, client.Workflow())
+
+	_, err = osdu.New(osdu.WithConfigFile(configFile, "prod"))
+	assert.ErrorContains(t, err, `profile "prod" not found`)
+
+	_, err = osdu.New(osdu.WithConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), ""))
+	assert.Error(t, err)
+}
@@ -3,99 +3,422 @@ package osdu
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
+	"net/url"
 
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
 	"github.com/heba920908/osdu-sdk-go/pkg/models"
 )
 
+// Upsert strategies of UpsertPartition
+const (
+	// UpsertCreateOnly fails with ErrPartitionExists when the partition exists
+	UpsertCreateOnly = "create-only"
+	// UpsertReplace deletes the partition and registers it again
+	UpsertReplace = "replace"
+	// UpsertMergePatch patches only the added and changed properties, properties missing
+	// from the partition are kept
+	UpsertMergePatch = "merge-patch"
+	// UpsertPatchAll patches every property of the partition when it exists
+	UpsertPatchAll = "patch-all"
+)
+
+// Actions of PartitionUpsertResult
+const (
+	PartitionCreated   = "created"
+	PartitionUpdated   = "updated"
+	PartitionUnchanged = "unchanged"
+	PartitionReplaced  = "replaced"
+)
+
+// PartitionUpsertResult tells what UpsertPartition did
+type PartitionUpsertResult struct {
+	PartitionId string `json:"partitionId"`
+	Strategy    string `json:"strategy"`
+	Action      string `json:"action"`
+	// Patched lists the properties sent by a merge-patch
+	Patched []string `json:"patched,omitempty"`
+}
+
+// RegisterPartition creates the partition, or patches every property when it exists
 func (a OsduApiRequest) RegisterPartition(partition models.Partition) error {
+	_, err := a.UpsertPartition(partition, UpsertPatchAll)
+	return err
+}
+
+// UpsertPartition registers the partition following strategy, the partition overrides are applied first
+func (a OsduApiRequest) UpsertPartition(partition models.Partition, strategy string) (PartitionUpsertResult, error) {
+	a = a._current()
+
+	partition, err := a._apply_partition_overrides(partition)
+	if err != nil {
+		return PartitionUpsertResult{}, err
+	}
+
 	partition_id := partition.Properties.DataPartitionId.Value
 
 	if len(partition_id) < 2 {
-		return fmt.Errorf("partition_id cannot be empty properties.dataPartitionId shouldn't be empty")
+		return PartitionUpsertResult{}, fmt.Errorf("partition_id cannot be empty properties.dataPartitionId shouldn't be empty")
 	}
 
 	ctx := context.WithValue(a.Context(), OsduApi, "register_partition")
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	result := PartitionUpsertResult{PartitionId: partition_id, Strategy: strategy}
+
+	// Only the properties with a value or flagged sensitive are sent, like PatchPartition does,
+	// so a property removed by the overrides is not written over the live one
+	body := map[string]interface{}{"properties": partition.Properties.ToMap()}
+	json_content, err := json.Marshal(body)
+	if err != nil {
+		return result, err
+	}
+
+	j, _ := json.MarshalIndent(body, "", "  ")
+	a._logger().InfoContext(ctx, fmt.Sprintf("Registering partition --- %s", strategy))
+	a._logger().DebugContext(ctx, string(j))
 
-	post_partition_url := fmt.Sprintf("%s/partitions/%s", a.osduSettings.PartitionUrl, partition_id)
+	switch strategy {
+	case UpsertCreateOnly:
+		if err := a._partition_write(ctx, http.MethodPost, partition_id, json_content); err != nil {
+			return result, err
+		}
+		result.Action = PartitionCreated
+
+	case UpsertPatchAll:
+		err := a._partition_write(ctx, http.MethodPost, partition_id, json_content)
+		action := PartitionCreated
+		if errors.Is(err, ErrPartitionExists) {
+			a._logger().Warn("Partition already created, trying to patch")
+			err = a._partition_write(ctx, http.MethodPatch, partition_id, json_content)
+			action = PartitionUpdated
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Action = action
+
+	case UpsertReplace:
+		_, err := a._partition_request(ctx, http.MethodDelete, partition_id, nil)
+		if err != nil && !errors.Is(err, ErrPartitionNotFound) {
+			return result, err
+		}
+		action := PartitionReplaced
+		if err != nil {
+			action = PartitionCreated
+		}
+		if err := a._partition_write(ctx, http.MethodPost, partition_id, json_content); err != nil {
+			return result, err
+		}
+		result.Action = action
+
+	case UpsertMergePatch:
+		live, err := a.Partition().GetPartition(partition_id)
+		if errors.Is(err, ErrPartitionNotFound) {
+			if err := a._partition_write(ctx, http.MethodPost, partition_id, json_content); err != nil {
+				return result, err
+			}
+			result.Action = PartitionCreated
+			break
+		}
+		if err != nil {
+			return result, err
+		}
 
-	json_content, err := json.Marshal(partition)
+		desired := partition.Properties.ToMap()
+		patch := models.PartitionPropertyMap{}
+		for _, change := range models.DiffPartition(partition, live).Changes {
+			if change.Kind != models.PartitionPropertyRemoved {
+				patch[change.Key] = desired[change.Key]
+			}
+		}
+		if len(patch) == 0 {
+			result.Action = PartitionUnchanged
+			break
+		}
+
+		patch_content, err := json.Marshal(map[string]interface{}{"properties": patch})
+		if err != nil {
+			return result, err
+		}
+		if err := a._partition_write(ctx, http.MethodPatch, partition_id, patch_content); err != nil {
+			return result, err
+		}
+		result.Action = PartitionUpdated
+		result.Patched = patch.Keys()
+
+	default:
+		return result, fmt.Errorf("unknown upsert strategy %q, expected one of %s, %s, %s, %s",
+			strategy, UpsertCreateOnly, UpsertReplace, UpsertMergePatch, UpsertPatchAll)
+	}
+
+	a._logger().InfoContext(ctx, fmt.Sprintf("Partition %s %s", partition_id, result.Action))
+	return result, nil
+}
+
+// _partition_write sends a POST or PATCH of partition_id and checks its response, which is
+// empty or a JSON document
+func (a OsduApiRequest) _partition_write(ctx context.Context, method, partition_id string, body []byte) error {
+	response, err := a._partition_request(ctx, method, partition_id, body)
 	if err != nil {
 		return err
 	}
+	a._logger().DebugContext(ctx, fmt.Sprintf("%s partition %s response: %s", method, partition_id, string(response)))
+
+	if len(bytes.TrimSpace(response)) > 0 && !json.Valid(response) {
+		return fmt.Errorf("%s partition %s: unexpected partition service response: %s", method, partition_id, string(response))
+	}
+	return nil
+}
 
-	j, _ := json.MarshalIndent(partition, "", "  ")
-	slog.InfoContext(ctx, "Registering partition ---")
-	slog.DebugContext(ctx, string(j))
+// _apply_partition_overrides applies the OsduSettings.PartitionOverrides file, if any, to partition
+func (a OsduApiRequest) _apply_partition_overrides(partition models.Partition) (models.Partition, error) {
+	if a.osduSettings.PartitionOverrides == "" {
+		return partition, nil
+	}
 
-	req, _ := http.NewRequest("POST", post_partition_url, bytes.NewBuffer([]byte(json_content)))
-	/* Partition from internal service does not need to use headers */
-	headers, _ := a._build_headers_without_partition()
-	req.Header = headers
-	//
+	overrides, err := models.LoadPartitionOverrides(a.osduSettings.PartitionOverrides)
+	if err != nil {
+		return partition, err
+	}
+	if partition.Properties, err = overrides.Apply(partition.Properties); err != nil {
+		return partition, err
+	}
+	a._logger().Info(fmt.Sprintf("Applied %d partition overrides from %s",
+		len(overrides.Properties), a.osduSettings.PartitionOverrides))
+	return partition, nil
+}
+
+// Errors wrapped by the errors of the partition service when the partition does not exist, or
+// already exists on creation
+var (
+	ErrPartitionNotFound = errors.New("partition not found")
+	ErrPartitionExists   = errors.New("partition already exists")
+)
+
+// PartitionClient implements PartitionService interface
+type PartitionClient struct {
+	apiClient *OsduApiRequest
+}
+
+// NewPartitionService creates a new PartitionService implementation
+func NewPartitionService(apiClient *OsduApiRequest) PartitionService {
+	return &PartitionClient{
+		apiClient: apiClient,
+	}
+}
+
+// PartitionService defines the interface for partition operations
+type PartitionService interface {
+	RegisterPartition(partition models.Partition) error
+	UpsertPartition(partition models.Partition, strategy string) (PartitionUpsertResult, error)
+	GetPartition(partition_id string) (models.Partition, error)
+	ListPartitions() ([]string, error)
+	PatchPartition(partition_id string, properties models.PartitionProperties) error
+	DeletePartition(partition_id string) error
+	DiffPartition(desired models.Partition) (models.PartitionDiff, error)
+	ExportPartitions(partition_ids ...string) ([]models.PartitionExport, error)
+	ImportPartitions(exports []models.PartitionExport, options models.PartitionImportOptions) error
+}
+
+// RegisterPartition implements the PartitionService interface
+func (p *PartitionClient) RegisterPartition(partition models.Partition) error {
+	return p.apiClient.RegisterPartition(partition)
+}
+
+// UpsertPartition implements the PartitionService interface
+func (p *PartitionClient) UpsertPartition(partition models.Partition, strategy string) (PartitionUpsertResult, error) {
+	return p.apiClient.UpsertPartition(partition, strategy)
+}
 
-	http_client := http.Client{}
+// GetPartition implements the PartitionService interface
+func (p *PartitionClient) GetPartition(partition_id string) (models.Partition, error) {
+	client := p.apiClient._current()
+	ctx := context.WithValue(client.Context(), OsduApi, "get_partition")
 
-	res, err := http_client.Do(req)
+	body, err := client._partition_request(ctx, http.MethodGet, partition_id, nil)
+	if err != nil {
+		return models.Partition{}, err
+	}
+
+	// The partition service returns the properties without the properties wrapper
+	var properties models.PartitionProperties
+	if err := json.Unmarshal(body, &properties); err != nil {
+		return models.Partition{}, fmt.Errorf("partition %s: %w", partition_id, err)
+	}
+	return models.Partition{Properties: properties}, nil
+}
+
+// ListPartitions implements the PartitionService interface
+func (p *PartitionClient) ListPartitions() ([]string, error) {
+	client := p.apiClient._current()
+	ctx := context.WithValue(client.Context(), OsduApi, "list_partitions")
+
+	body, err := client._partition_request(ctx, http.MethodGet, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []string
+	if err := json.Unmarshal(body, &partitions); err != nil {
+		return nil, err
+	}
+	return partitions, nil
+}
+
+// PatchPartition implements the PartitionService interface, only the properties with a value
+// or flagged sensitive are sent (Extra included), the others are left untouched
+func (p *PartitionClient) PatchPartition(partition_id string, properties models.PartitionProperties) error {
+	client := p.apiClient._current()
+	ctx := context.WithValue(client.Context(), OsduApi, "patch_partition")
+
+	patch := properties.ToMap()
+	json_content, err := json.Marshal(map[string]interface{}{"properties": patch})
 	if err != nil {
-		slog.ErrorContext(ctx, err.Error())
 		return err
 	}
 
-	slog.Info(fmt.Sprintf("%d", res.StatusCode))
+	client._logger().InfoContext(ctx, fmt.Sprintf("Patching %d properties of partition %s", len(patch), partition_id))
+	if _, err := client._partition_request(ctx, http.MethodPatch, partition_id, json_content); err != nil {
+		return err
+	}
 
-	defer res.Body.Close()
+	client._logger().InfoContext(ctx, fmt.Sprintf("Partition %s patched", partition_id))
+	return nil
+}
+
+// DeletePartition implements the PartitionService interface
+func (p *PartitionClient) DeletePartition(partition_id string) error {
+	client := p.apiClient._current()
+	ctx := context.WithValue(client.Context(), OsduApi, "delete_partition")
+
+	if _, err := client._partition_request(ctx, http.MethodDelete, partition_id, nil); err != nil {
+		return err
+	}
+
+	client._logger().InfoContext(ctx, fmt.Sprintf("Partition %s deleted", partition_id))
+	return nil
+}
+
+// DiffPartition implements the PartitionService interface, it compares desired (with the partition
+// overrides applied, as RegisterPartition does) to the live partition
+func (p *PartitionClient) DiffPartition(desired models.Partition) (models.PartitionDiff, error) {
+	client := p.apiClient._current()
+	ctx := context.WithValue(client.Context(), OsduApi, "diff_partition")
+
+	desired, err := client._apply_partition_overrides(desired)
+	if err != nil {
+		return models.PartitionDiff{}, err
+	}
+
+	partition_id := desired.Properties.DataPartitionId.Value
+	if len(partition_id) < 2 {
+		return models.PartitionDiff{}, fmt.Errorf("partition_id cannot be empty properties.dataPartitionId shouldn't be empty")
+	}
+
+	live, err := p.GetPartition(partition_id)
+	exists := !errors.Is(err, ErrPartitionNotFound)
+	if err != nil && exists {
+		return models.PartitionDiff{}, err
+	}
 
-	if res.StatusCode == http.StatusConflict {
-		slog.Warn("Partition already created, trying to patch")
-		req, _ = http.NewRequest("PATCH", post_partition_url, bytes.NewBuffer([]byte(json_content)))
-		req.Header = headers
-		res, err = http_client.Do(req)
+	diff := models.DiffPartition(desired, live)
+	diff.Exists = exists
+	client._logger().InfoContext(ctx, fmt.Sprintf("Partition %s drift: %d changes", partition_id, len(diff.Changes)))
+	return diff, nil
+}
+
+// ExportPartitions implements the PartitionService interface, every partition is exported when
+// no id is given. Sensitive values are exported as stored, as secret references.
+func (p *PartitionClient) ExportPartitions(partition_ids ...string) ([]models.PartitionExport, error) {
+	if len(partition_ids) == 0 {
+		ids, err := p.ListPartitions()
 		if err != nil {
-			slog.ErrorContext(ctx, err.Error())
-			return err
+			return nil, err
 		}
+		partition_ids = ids
 	}
 
-	if res.StatusCode > 205 {
-		body_bytes, err := io.ReadAll(res.Body)
+	exports := make([]models.PartitionExport, 0, len(partition_ids))
+	for _, partition_id := range partition_ids {
+		partition, err := p.GetPartition(partition_id)
 		if err != nil {
-			slog.Error(err.Error())
+			return nil, err
 		}
-		status_err := fmt.Errorf("partition service response - %d : %s", res.StatusCode, string(body_bytes))
-		slog.ErrorContext(ctx, status_err.Error())
-		return status_err
+		export := models.NewPartitionExport(partition)
+		// The live properties may not hold dataPartitionId
+		export.PartitionId = partition_id
+		exports = append(exports, export)
 	}
-
-	slog.InfoContext(ctx, fmt.Sprintf("Partition %s registered", partition_id))
-	return nil
+	return exports, nil
 }
 
-func (a OsduApiRequest) _clean_up_partition(partitionid string) error {
-	ctx := context.WithValue(a.Context(), OsduApi, "cleanup_partition")
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+// ImportPartitions implements the PartitionService interface, each partition is registered as
+// RegisterPartition does. Nothing is registered when a partition cannot be remapped.
+func (p *PartitionClient) ImportPartitions(exports []models.PartitionExport, options models.PartitionImportOptions) error {
+	partitions := make([]models.Partition, 0, len(exports))
+	var errs []error
+	for _, export := range exports {
+		partition, err := export.Partition(options)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		partitions = append(partitions, partition)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
 
-	delete_partition_url := fmt.Sprintf("%s/partitions/%s", a.osduSettings.PartitionUrl, partitionid)
+	for _, partition := range partitions {
+		if err := p.apiClient.RegisterPartition(partition); err != nil {
+			errs = append(errs, fmt.Errorf("partition %s: %w", partition.Properties.DataPartitionId.Value, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// _partition_request calls /partitions or /partitions/{partition_id} and returns the response body.
+// Partition from internal service does not need a token when skipToken is set for it.
+func (a OsduApiRequest) _partition_request(ctx context.Context, method, partition_id string, body []byte) ([]byte, error) {
+	partitions_url := fmt.Sprintf("%s/partitions", a.osduSettings.PartitionUrl)
+	if partition_id != "" {
+		partitions_url = fmt.Sprintf("%s/%s", partitions_url, url.PathEscape(partition_id))
+	}
 
-	req, _ := http.NewRequest(http.MethodDelete, delete_partition_url, nil)
-	headers, _ := a._build_headers_without_partition()
-	req.Header = headers
+	res, err := a._http_request_without_partition(config.ServicePartition, method, partitions_url, body)
+	if err != nil {
+		// Transport errors already name the URL, header errors (i.e. no token) do not
+		var url_err *url.Error
+		if !errors.As(err, &url_err) {
+			err = fmt.Errorf("%s %s: %w", method, partitions_url, err)
+		}
+		a._logger().ErrorContext(ctx, err.Error())
+		return nil, err
+	}
+	defer res.Body.Close()
 
-	http_client := http.Client{}
+	a._logger().DebugContext(ctx, fmt.Sprintf("%s partition %s StatusCode: %d", method, partition_id, res.StatusCode))
 
-	res, err := http_client.Do(req)
+	body_bytes, err := io.ReadAll(res.Body)
 	if err != nil {
-		slog.Error(err.Error())
-		return err
+		return nil, err
 	}
 
-	slog.InfoContext(ctx, fmt.Sprintf("DELETE partition %s StatusCode: %d", partitionid, res.StatusCode))
+	if res.StatusCode == http.StatusNotFound && partition_id != "" {
+		return nil, fmt.Errorf("%w: %s", ErrPartitionNotFound, partition_id)
+	}
 
-	return nil
+	if res.StatusCode == http.StatusConflict && method == http.MethodPost {
+		return nil, fmt.Errorf("%w: %s", ErrPartitionExists, partition_id)
+	}
+
+	if res.StatusCode > 205 {
+		status_err := fmt.Errorf("partition service response - %d : %s", res.StatusCode, string(body_bytes))
+		a._logger().ErrorContext(ctx, status_err.Error())
+		return nil, status_err
+	}
+	return body_bytes, nil
 }
@@ -1,13 +1,20 @@
 package osdu_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
 	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -165,3 +172,585 @@ func TestMockPartitionWithOverride(t *testing.T) {
 	err = client.RegisterPartition(finalPartition)
 	assert.NoError(t, err)
 }
+
+func TestMockPartitionWithOverridesFile(t *testing.T) {
+	overridesFile := filepath.Join(t.TempDir(), "overrides.yaml")
+	err := os.WriteFile(overridesFile, []byte(`
+properties:
+  obm.minio.endpoint:
+    value: http://test-minion:9000
+  elasticsearch.8.password:
+    sensitive: false
+  obm.minio.ui.endpoint:
+    remove: true
+  elasticsearch.8.user: ELASTIC_USER_OVERRIDE
+`), 0600)
+	assert.NoError(t, err)
+
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var partition models.Partition
+		assert.NoError(t, json.Unmarshal(data, &partition))
+
+		// A removed property is left out of the request, not sent empty
+		var body struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		assert.NoError(t, json.Unmarshal(data, &body))
+		assert.NotContains(t, body.Properties, "obm.minio.ui.endpoint")
+		assert.Contains(t, body.Properties, "obm.minio.endpoint")
+
+		properties := partition.Properties
+		assert.Equal(t, models.PartitionProperty{Value: "http://test-minion:9000"}, properties.ObmMinioEndpoint)
+		assert.False(t, properties.ElasticsearchPassword.Sensitive)
+		assert.NotEmpty(t, properties.ElasticsearchPassword.Value)
+		assert.Equal(t, models.PartitionProperty{}, properties.ObmMinioUiEndpoint)
+		assert.Equal(t, "ELASTIC_USER_OVERRIDE", properties.ElasticsearchUser.Value)
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer partitionServer.Close()
+
+	_, mockAuth := createMockClient(partitionServer.URL, "http://mock-entitlements")
+	client := osdu.NewClientWithConfig(mockAuth, config.OsduSettings{
+		PartitionId:        "test-partition",
+		PartitionUrl:       partitionServer.URL,
+		PartitionOverrides: overridesFile,
+	})
+
+	partition := models.Partition{Properties: models.GetDefaultPartitionPropertiesCI("test-partition")}
+	assert.NoError(t, client.RegisterPartition(partition))
+}
+
+func TestMockPartitionWithInvalidOverridesFile(t *testing.T) {
+	overridesFile := filepath.Join(t.TempDir(), "overrides.yaml")
+	err := os.WriteFile(overridesFile, []byte(`
+properties:
+  obm.minio.endpoin:
+    value: http://test-minion:9000
+`), 0600)
+	assert.NoError(t, err)
+
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("partition must not be registered with invalid overrides")
+	}))
+	defer partitionServer.Close()
+
+	_, mockAuth := createMockClient(partitionServer.URL, "http://mock-entitlements")
+	client := osdu.NewClientWithConfig(mockAuth, config.OsduSettings{
+		PartitionId:        "test-partition",
+		PartitionUrl:       partitionServer.URL,
+		PartitionOverrides: overridesFile,
+	})
+
+	partition := models.Partition{Properties: models.GetDefaultPartitionPropertiesCI("test-partition")}
+	err = client.RegisterPartition(partition)
+	assert.ErrorContains(t, err, `unknown partition property "obm.minio.endpoin"`)
+}
+
+func TestMockPartitionService(t *testing.T) {
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer mock-access-token", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("data-partition-id"))
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /partitions":
+			w.Write([]byte(`["opendes", "tenant-a"]`))
+		case "GET /partitions/opendes":
+			w.Write([]byte(`{
+				"dataPartitionId": {"sensitive": false, "value": "opendes"},
+				"osm.postgres.datasource.password": {"sensitive": true, "value": "POSTGRES_DB_PASSWORD_OPENDES"}
+			}`))
+		case "PATCH /partitions/opendes":
+			var patch map[string]map[string]models.PartitionProperty
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+			assert.Equal(t, map[string]models.PartitionProperty{
+				"obm.minio.endpoint": {Value: "http://minio:9000"},
+			}, patch["properties"])
+			w.WriteHeader(http.StatusNoContent)
+		case "DELETE /partitions/opendes":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer partitionServer.Close()
+
+	client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+	partitions := client.Partition()
+
+	ids, err := partitions.ListPartitions()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"opendes", "tenant-a"}, ids)
+
+	partition, err := partitions.GetPartition("opendes")
+	assert.NoError(t, err)
+	assert.Equal(t, "opendes", partition.Properties.DataPartitionId.Value)
+	assert.Equal(t, models.PartitionProperty{Sensitive: true, Value: "POSTGRES_DB_PASSWORD_OPENDES"},
+		partition.Properties.OsmPostgresDatasourcePassword)
+
+	properties := models.PartitionProperties{}
+	properties.ObmMinioEndpoint.Value = "http://minio:9000"
+	assert.NoError(t, partitions.PatchPartition("opendes", properties))
+
+	assert.NoError(t, partitions.DeletePartition("opendes"))
+
+	_, err = partitions.GetPartition("missing")
+	assert.ErrorIs(t, err, osdu.ErrPartitionNotFound)
+	err = partitions.DeletePartition("missing")
+	assert.ErrorIs(t, err, osdu.ErrPartitionNotFound)
+	err = partitions.PatchPartition("missing", properties)
+	assert.ErrorIs(t, err, osdu.ErrPartitionNotFound)
+}
+
+func TestMockPartitionServiceError(t *testing.T) {
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "forbidden"}`))
+	}))
+	defer partitionServer.Close()
+
+	client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+
+	_, err := client.Partition().ListPartitions()
+	assert.ErrorContains(t, err, "partition service response - 403")
+	err = client.Partition().DeletePartition("opendes")
+	assert.ErrorContains(t, err, "partition service response - 403")
+	assert.NotErrorIs(t, err, osdu.ErrPartitionNotFound)
+}
+
+func TestMockPartitionUnknownPropertiesRoundTrip(t *testing.T) {
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{
+				"dataPartitionId": {"sensitive": false, "value": "opendes"},
+				"featureFlag.new-release.enabled": {"sensitive": false, "value": "true"},
+				"new.release.secret": {"sensitive": true, "value": "NEW_RELEASE_SECRET"}
+			}`))
+		case http.MethodPatch:
+			var patch map[string]models.PartitionPropertyMap
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+			assert.Equal(t, models.PartitionPropertyMap{
+				models.PropertyDataPartitionId:    {Value: "opendes"},
+				models.PropertyObmMinioEndpoint:   {Value: "http://minio:9000"},
+				"featureFlag.new-release.enabled": {Value: "false"},
+				"new.release.secret":              {Value: "NEW_RELEASE_SECRET", Sensitive: true},
+			}, patch["properties"])
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer partitionServer.Close()
+
+	client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+
+	partition, err := client.Partition().GetPartition("opendes")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"featureFlag.new-release.enabled", "new.release.secret"}, partition.Properties.ToMap().Unknown())
+
+	partition.Properties.ObmMinioEndpoint.Value = "http://minio:9000"
+	partition.Properties.Extra.Set("featureFlag.new-release.enabled", "false", false)
+	assert.NoError(t, client.Partition().PatchPartition("opendes", partition.Properties))
+
+	// The struct and map representations convert to each other without loss
+	properties := partition.Properties.ToMap()
+	assert.Equal(t, partition.Properties, properties.ToProperties())
+	assert.Equal(t, "http://minio:9000", properties.Value(models.PropertyObmMinioEndpoint))
+	assert.True(t, properties.Sensitive("new.release.secret"))
+}
+
+func TestPartitionTemplates(t *testing.T) {
+	for _, template := range models.PartitionTemplates {
+		t.Run(template, func(t *testing.T) {
+			properties, err := models.GetDefaultPartitionProperties(template, "opendes")
+			assert.NoError(t, err)
+			assert.Equal(t, "opendes", properties.DataPartitionId.Value)
+			assert.NoError(t, models.ValidatePartitionTemplate(template, properties))
+
+			for _, key := range models.PartitionTemplateRequired(template) {
+				assert.True(t, models.IsKnownPartitionProperty(key), key)
+			}
+		})
+	}
+
+	azure := models.GetDefaultPartitionPropertiesAzure("opendes")
+	assert.Equal(t, models.PartitionProperty{Value: "opendes-cosmos-endpoint", Sensitive: true}, azure.CosmosEndpoint)
+
+	aws := models.GetDefaultPartitionPropertiesAWS("opendes")
+	assert.Equal(t, models.PartitionProperty{Value: "/osdu/opendes/elasticsearch/password", Sensitive: true}, aws.ElasticsearchPassword)
+
+	gcp := models.GetDefaultPartitionPropertiesGCP("opendes")
+	assert.Equal(t, models.PartitionProperty{Value: "ELASTIC_PASS_OPENDES", Sensitive: true}, gcp.ElasticsearchPassword)
+
+	// The CI template does not fill the Azure properties
+	err := models.ValidatePartitionTemplate(models.PartitionTemplateAzure, models.GetDefaultPartitionPropertiesCI("opendes"))
+	assert.ErrorContains(t, err, `azure partition template requires property "cosmos-endpoint"`)
+	assert.ErrorContains(t, err, `azure partition template requires property "sb-namespace"`)
+
+	_, err = models.GetDefaultPartitionProperties("ibm", "opendes")
+	assert.ErrorContains(t, err, `unknown partition template "ibm"`)
+}
+
+func TestPartitionTemplateCISensitivity(t *testing.T) {
+	properties := models.GetDefaultPartitionPropertiesCI("opendes").ToMap()
+
+	// The https and tls flags no longer overwrite the sensitivity of the password
+	assert.True(t, properties[models.PropertyElasticsearchPassword].Sensitive)
+	assert.False(t, properties[models.PropertyElasticsearchHttps].Sensitive)
+	assert.False(t, properties[models.PropertyElasticsearchTls].Sensitive)
+}
+
+func TestMockPartitionDiff(t *testing.T) {
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		if r.URL.Path != "/partitions/opendes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{
+			"dataPartitionId": {"sensitive": false, "value": "opendes"},
+			"bucket": {"sensitive": false, "value": "refi-opendes-records"},
+			"osm.postgres.datasource.password": {"sensitive": true, "value": "LIVE_SECRET_VALUE"},
+			"elasticsearch.8.user": {"sensitive": false, "value": "ELASTIC_USER_OPENDES"},
+			"legacy.property": {"sensitive": false, "value": "true"}
+		}`))
+	}))
+	defer partitionServer.Close()
+
+	client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+
+	desired := models.PartitionProperties{}
+	desired.DataPartitionId.Value = "opendes"
+	desired.Bucket.Value = "osdu-opendes-records"
+	desired.OsmPostgresDatasourcePassword = models.PartitionProperty{Value: "DESIRED_SECRET_VALUE", Sensitive: true}
+	desired.ElasticsearchUser = models.PartitionProperty{Value: "ELASTIC_USER_OPENDES", Sensitive: true}
+	desired.ObmMinioEndpoint.Value = "http://minio:9000"
+
+	diff, err := client.Partition().DiffPartition(models.Partition{Properties: desired})
+	assert.NoError(t, err)
+	assert.True(t, diff.Exists)
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, 1, diff.Count(models.PartitionPropertyAdded))
+	assert.Equal(t, 2, diff.Count(models.PartitionPropertyChanged))
+	assert.Equal(t, 1, diff.Count(models.PartitionPropertyRemoved))
+	assert.Equal(t, 1, diff.Count(models.PartitionPropertySensitivity))
+
+	text := diff.String()
+	assert.Contains(t, text, `+ obm.minio.endpoint = "http://minio:9000"`)
+	assert.Contains(t, text, `~ bucket: "refi-opendes-records" -> "osdu-opendes-records"`)
+	assert.Contains(t, text, `~ osm.postgres.datasource.password: ****** -> ******`)
+	assert.Contains(t, text, `- legacy.property (was "true")`)
+	assert.Contains(t, text, `! elasticsearch.8.user: not sensitive -> sensitive`)
+
+	report, err := diff.JSON()
+	assert.NoError(t, err)
+	live_hash := sha256.Sum256([]byte("LIVE_SECRET_VALUE"))
+	for _, out := range []string{text, string(report)} {
+		assert.NotContains(t, out, "LIVE_SECRET_VALUE")
+		assert.NotContains(t, out, "DESIRED_SECRET_VALUE")
+		// Nothing derived from a sensitive value is reported, a hash can be guessed
+		assert.NotContains(t, out, hex.EncodeToString(live_hash[:]))
+	}
+	assert.NotContains(t, string(report), `"hash"`)
+
+	var decoded models.PartitionDiff
+	assert.NoError(t, json.Unmarshal(report, &decoded))
+	assert.Equal(t, diff, decoded)
+
+	// A partition not registered yet is reported as fully added
+	desired.DataPartitionId.Value = "tenant-a"
+	diff, err = client.Partition().DiffPartition(models.Partition{Properties: desired})
+	assert.NoError(t, err)
+	assert.False(t, diff.Exists)
+	assert.Equal(t, 5, diff.Count(models.PartitionPropertyAdded))
+	assert.Contains(t, diff.String(), "partition tenant-a (not registered)")
+}
+
+func TestPartitionSecrets(t *testing.T) {
+	properties := models.GetDefaultPartitionPropertiesCI("opendes")
+	keys := models.PartitionSecretKeys(properties)
+	assert.Contains(t, keys, "POSTGRES_DB_PASSWORD_OPENDES")
+	assert.Contains(t, keys, "MINIO_SECRET_KEY_OPENDES")
+	assert.NotContains(t, keys, "refi-opendes-records")
+
+	manifest, err := models.GeneratePartitionSecrets(properties, models.PartitionSecretOptions{
+		Namespace:   "osdu",
+		SecretStore: "vault",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(manifest), "kind: Secret\nmetadata:\n  name: eds-osdu\n  namespace: osdu\n")
+	assert.Contains(t, string(manifest), "  POSTGRES_DB_PASSWORD_OPENDES: \"\"\n")
+	assert.Contains(t, string(manifest), "kind: ExternalSecret\n")
+	assert.Contains(t, string(manifest), "    - secretKey: MINIO_SECRET_KEY_OPENDES\n      remoteRef:\n        key: MINIO_SECRET_KEY_OPENDES\n")
+
+	// The generated manifest holds every key
+	missing, err := models.CheckPartitionSecrets(properties, manifest)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+
+	secret := `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: eds-osdu
+data:
+  POSTGRES_DB_PASSWORD_OPENDES: cGFzcw==
+`
+	missing, err = models.CheckPartitionSecrets(properties, []byte(secret))
+	assert.NoError(t, err)
+	assert.NotContains(t, missing, "POSTGRES_DB_PASSWORD_OPENDES")
+	assert.Contains(t, missing, "MINIO_SECRET_KEY_OPENDES")
+
+	_, err = models.CheckPartitionSecrets(properties, []byte(strings.Replace(secret, "eds-osdu", "other", 1)))
+	assert.ErrorContains(t, err, "no Secret or ExternalSecret named eds-osdu")
+
+	envFile := filepath.Join(t.TempDir(), "secrets.env")
+	var env strings.Builder
+	env.WriteString("# partition opendes\n")
+	for _, key := range keys {
+		if key != "MINIO_SECRET_KEY_OPENDES" {
+			env.WriteString("export " + key + "=value\n")
+		}
+	}
+	assert.NoError(t, os.WriteFile(envFile, []byte(env.String()), 0600))
+	missing, err = models.CheckPartitionSecretsFile(properties, envFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MINIO_SECRET_KEY_OPENDES"}, missing)
+
+	// AWS secret names are paths, not Secret keys
+	_, err = models.GeneratePartitionSecrets(models.GetDefaultPartitionPropertiesAWS("opendes"), models.PartitionSecretOptions{})
+	assert.ErrorContains(t, err, "not valid Kubernetes secret keys: /osdu/opendes/elasticsearch/host")
+}
+
+func TestMockPartitionExportImport(t *testing.T) {
+	var registered []models.Partition
+	partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /partitions":
+			w.Write([]byte(`["opendes"]`))
+		case "GET /partitions/opendes":
+			w.Write([]byte(`{
+				"dataPartitionId": {"sensitive": false, "value": "opendes"},
+				"name": {"sensitive": false, "value": "opendes"},
+				"bucket": {"sensitive": false, "value": "refi-opendes-records"},
+				"obm.minio.endpoint": {"sensitive": false, "value": "http://minio-dev:9000"},
+				"osm.postgres.datasource.password": {"sensitive": true, "value": "POSTGRES_DB_PASSWORD_OPENDES"},
+				"new.release.property": {"sensitive": false, "value": "true"}
+			}`))
+		case "POST /partitions/tenant-a":
+			var partition models.Partition
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&partition))
+			registered = append(registered, partition)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer partitionServer.Close()
+
+	client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+
+	exports, err := client.Partition().ExportPartitions()
+	assert.NoError(t, err)
+	assert.Len(t, exports, 1)
+	// Sensitive values are exported as references
+	assert.Equal(t, "POSTGRES_DB_PASSWORD_OPENDES", exports[0].Properties.Value(models.PropertyOsmPostgresDatasourcePassword))
+
+	// Make the export environment independent, as kept in git
+	exports[0].Properties.Set(models.PropertyObmMinioEndpoint, "http://${minioHost}:9000", false)
+
+	dir := t.TempDir()
+	for _, file := range []string{"opendes.yaml", "opendes.json"} {
+		path := filepath.Join(dir, file)
+		assert.NoError(t, models.WritePartitionExport(path, exports))
+		loaded, err := models.LoadPartitionExport(path)
+		assert.NoError(t, err)
+		assert.Equal(t, exports, loaded)
+	}
+
+	// Undefined values fail before anything is registered
+	options := models.PartitionImportOptions{
+		PartitionIds:   map[string]string{"opendes": "tenant-a"},
+		RenameInValues: true,
+	}
+	err = client.Partition().ImportPartitions(exports, options)
+	assert.ErrorContains(t, err, "partition opendes: undefined value ${minioHost}")
+	assert.Empty(t, registered)
+
+	valuesFile := filepath.Join(dir, "prod.yaml")
+	assert.NoError(t, os.WriteFile(valuesFile, []byte("minioHost: minio-prod\n"), 0600))
+	options.Values, err = models.LoadPartitionValues(valuesFile)
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.Partition().ImportPartitions(exports, options))
+	assert.Len(t, registered, 1)
+	properties := registered[0].Properties
+	assert.Equal(t, "tenant-a", properties.DataPartitionId.Value)
+	assert.Equal(t, "tenant-a", properties.Name.Value)
+	assert.Equal(t, "refi-tenant-a-records", properties.Bucket.Value)
+	assert.Equal(t, "http://minio-prod:9000", properties.ObmMinioEndpoint.Value)
+	assert.Equal(t, models.PartitionProperty{Value: "POSTGRES_DB_PASSWORD_TENANT-A", Sensitive: true}, properties.OsmPostgresDatasourcePassword)
+	assert.Equal(t, "true", properties.Extra.Value("new.release.property"))
+}
+
+func TestPartitionImportRenameInValues(t *testing.T) {
+	export := models.PartitionExport{
+		PartitionId: "dp1",
+		Properties: models.PartitionPropertyMap{
+			models.PropertyDataPartitionId:               {Value: "dp1"},
+			models.PropertyBucket:                        {Value: "refi-dp1-records"},
+			models.PropertyObmMinioEndpoint:              {Value: "http://adp1.minio.local:9000/dp10"},
+			models.PropertyOsmPostgresDatasourcePassword: {Value: "POSTGRES_DB_PASSWORD_DP1", Sensitive: true},
+			"gcp.serviceAccount":                         {Value: "datafier@gdp1.iam.gserviceaccount.com", Sensitive: true},
+			"storage.path":                               {Value: "gs://dp1dp1/dp1/dp1_dp1"},
+		},
+	}
+
+	partition, err := export.Partition(models.PartitionImportOptions{
+		PartitionIds:   map[string]string{"dp1": "tenant-a"},
+		RenameInValues: true,
+	})
+	assert.NoError(t, err)
+	properties := partition.Properties
+	assert.Equal(t, "tenant-a", properties.DataPartitionId.Value)
+	assert.Equal(t, "refi-tenant-a-records", properties.Bucket.Value)
+	// The id inside another token is left as it is
+	assert.Equal(t, "http://adp1.minio.local:9000/dp10", properties.ObmMinioEndpoint.Value)
+	assert.Equal(t, "POSTGRES_DB_PASSWORD_TENANT-A", properties.OsmPostgresDatasourcePassword.Value)
+	assert.Equal(t, "datafier@gdp1.iam.gserviceaccount.com", properties.Extra.Value("gcp.serviceAccount"))
+	assert.Equal(t, "gs://dp1dp1/tenant-a/tenant-a_tenant-a", properties.Extra.Value("storage.path"))
+}
+
+func TestMockPartitionUpsertStrategies(t *testing.T) {
+	live := `{
+		"dataPartitionId": {"sensitive": false, "value": "opendes"},
+		"bucket": {"sensitive": false, "value": "refi-opendes-records"},
+		"legacy.property": {"sensitive": false, "value": "true"}
+	}`
+
+	desired := models.PartitionProperties{}
+	desired.DataPartitionId.Value = "opendes"
+	desired.Bucket.Value = "refi-opendes-records"
+
+	changed := desired
+	changed.Bucket.Value = "osdu-opendes-records"
+	changed.ObmMinioEndpoint.Value = "http://minio:9000"
+
+	tests := []struct {
+		name       string
+		strategy   string
+		exists     bool
+		properties models.PartitionProperties
+		requests   []string
+		action     string
+		patched    []string
+		err        error
+	}{
+		{"create-only new", osdu.UpsertCreateOnly, false, desired, []string{"POST"}, osdu.PartitionCreated, nil, nil},
+		{"create-only existing", osdu.UpsertCreateOnly, true, desired, []string{"POST"}, "", nil, osdu.ErrPartitionExists},
+		{"patch-all new", osdu.UpsertPatchAll, false, desired, []string{"POST"}, osdu.PartitionCreated, nil, nil},
+		{"patch-all existing", osdu.UpsertPatchAll, true, desired, []string{"POST", "PATCH"}, osdu.PartitionUpdated, nil, nil},
+		{"replace new", osdu.UpsertReplace, false, desired, []string{"DELETE", "POST"}, osdu.PartitionCreated, nil, nil},
+		{"replace existing", osdu.UpsertReplace, true, desired, []string{"DELETE", "POST"}, osdu.PartitionReplaced, nil, nil},
+		{"merge-patch new", osdu.UpsertMergePatch, false, desired, []string{"GET", "POST"}, osdu.PartitionCreated, nil, nil},
+		{"merge-patch unchanged", osdu.UpsertMergePatch, true, desired, []string{"GET"}, osdu.PartitionUnchanged, nil, nil},
+		{"merge-patch changed", osdu.UpsertMergePatch, true, changed, []string{"GET", "PATCH"}, osdu.PartitionUpdated,
+			[]string{models.PropertyBucket, models.PropertyObmMinioEndpoint}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			partitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/partitions/opendes", r.URL.Path)
+				requests = append(requests, r.Method)
+
+				switch {
+				case r.Method == http.MethodGet && tt.exists:
+					w.Write([]byte(live))
+				case r.Method == http.MethodPost && tt.exists && tt.strategy != osdu.UpsertReplace:
+					w.WriteHeader(http.StatusConflict)
+				case r.Method == http.MethodPatch && tt.strategy == osdu.UpsertMergePatch:
+					var patch map[string]models.PartitionPropertyMap
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+					assert.Equal(t, tt.patched, patch["properties"].Keys())
+					w.WriteHeader(http.StatusNoContent)
+				case r.Method == http.MethodDelete && !tt.exists, r.Method == http.MethodGet:
+					w.WriteHeader(http.StatusNotFound)
+				case r.Method == http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+				default:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer partitionServer.Close()
+
+			client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+
+			result, err := client.Partition().UpsertPartition(models.Partition{Properties: tt.properties}, tt.strategy)
+			assert.Equal(t, tt.requests, requests)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, osdu.PartitionUpsertResult{
+				PartitionId: "opendes",
+				Strategy:    tt.strategy,
+				Action:      tt.action,
+				Patched:     tt.patched,
+			}, result)
+		})
+	}
+
+	client, _ := createMockClient("http://unused-partition", "http://mock-entitlements")
+	_, err := client.UpsertPartition(models.Partition{Properties: desired}, "upsert")
+	assert.ErrorContains(t, err, `unknown upsert strategy "upsert"`)
+}
+
+func TestMockPartitionUpsertFailures(t *testing.T) {
+	properties := models.PartitionProperties{}
+	properties.DataPartitionId.Value = "opendes"
+
+	tests := []struct {
+		name     string
+		strategy string
+		handler  func(w http.ResponseWriter, r *http.Request)
+		err      string
+	}{
+		{"create fails", osdu.UpsertPatchAll, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, "partition service response - 500"},
+		{"patch fails", osdu.UpsertPatchAll, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		}, "partition service response - 400"},
+		{"patch response is not JSON", osdu.UpsertPatchAll, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.Write([]byte("<html>proxy error</html>"))
+		}, "PATCH partition opendes: unexpected partition service response: <html>proxy error</html>"},
+		{"create-only fails", osdu.UpsertCreateOnly, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}, "partition service response - 403"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partitionServer := httptest.NewServer(http.HandlerFunc(tt.handler))
+			defer partitionServer.Close()
+
+			client, _ := createMockClient(partitionServer.URL, "http://mock-entitlements")
+			result, err := client.UpsertPartition(models.Partition{Properties: properties}, tt.strategy)
+			assert.ErrorContains(t, err, tt.err)
+			assert.Empty(t, result.Action, "no action is reported for a failed request")
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package osdu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+)
+
+// Steps of a partition provisioning, run in this order
+const (
+	ProvisionStepPartition    = "partition"
+	ProvisionStepEntitlements = "entitlements"
+	ProvisionStepAdminUser    = "admin-user"
+)
+
+// DefaultProvisionWorkers is the number of partitions provisioned at once by default
+const DefaultProvisionWorkers = 4
+
+// PartitionProvisioning describes a partition to provision
+type PartitionProvisioning struct {
+	Partition models.Partition
+	// Strategy registers the partition, UpsertPatchAll when empty
+	Strategy string
+	// AdminUser is added as owner of the admin groups, ProvisionOptions.AdminUser when empty.
+	// The step is skipped without admin user.
+	AdminUser string
+}
+
+// ProvisionOptions configures ProvisionPartitions
+type ProvisionOptions struct {
+	// Workers bounds the partitions provisioned at once, DefaultProvisionWorkers when not set
+	Workers int
+	// AdminUser is the default admin user of the partitions
+	AdminUser string
+}
+
+// ProvisionStepResult is the outcome of a single step
+type ProvisionStepResult struct {
+	Step     string        `json:"step"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	err      error
+}
+
+// Err returns the error of the step, nil when it succeeded or was skipped
+func (s ProvisionStepResult) Err() error {
+	return s.err
+}
+
+// PartitionProvisionResult is the outcome of the provisioning of a partition. The steps after
+// a failed step are skipped.
+type PartitionProvisionResult struct {
+	PartitionId string                `json:"partitionId"`
+	Upsert      PartitionUpsertResult `json:"upsert"`
+	Steps       []ProvisionStepResult `json:"steps"`
+	Duration    time.Duration         `json:"duration"`
+}
+
+// Err returns the error of the failed step, nil when the partition is provisioned
+func (r PartitionProvisionResult) Err() error {
+	for _, step := range r.Steps {
+		if step.err != nil {
+			return fmt.Errorf("partition %s: %s: %w", r.PartitionId, step.Step, step.err)
+		}
+	}
+	return nil
+}
+
+// Failed reports whether a step failed
+func (r PartitionProvisionResult) Failed() bool {
+	return r.Err() != nil
+}
+
+// ProvisionPartitions registers each partition, provisions its entitlements tenant and adds its
+// admin user, options.Workers partitions at once. A failed partition does not stop the others:
+// a result is returned per partition, in the order of partitions, along with the joined errors.
+func (a OsduApiRequest) ProvisionPartitions(partitions []PartitionProvisioning, options ProvisionOptions) ([]PartitionProvisionResult, error) {
+	a = a._current()
+	ctx := context.WithValue(a.Context(), OsduApi, "provision_partitions")
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = DefaultProvisionWorkers
+	}
+	a._logger().InfoContext(ctx, fmt.Sprintf("Provisioning %d partitions with %d workers", len(partitions), workers))
+
+	results := make([]PartitionProvisionResult, len(partitions))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(partitions); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = a._provision_partition(ctx, partitions[i], options)
+			}
+		}()
+	}
+	for i := range partitions {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if err := result.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	a._logger().InfoContext(ctx, fmt.Sprintf("Provisioned %d partitions, %d failed", len(partitions)-len(errs), len(errs)))
+	return results, errors.Join(errs...)
+}
+
+// _provision_partition runs the steps of a partition with a client bound to it
+func (a OsduApiRequest) _provision_partition(ctx context.Context, p PartitionProvisioning, options ProvisionOptions) PartitionProvisionResult {
+	start := time.Now()
+	partition_id := p.Partition.Properties.DataPartitionId.Value
+	result := PartitionProvisionResult{PartitionId: partition_id}
+
+	strategy := p.Strategy
+	if strategy == "" {
+		strategy = UpsertPatchAll
+	}
+	admin := p.AdminUser
+	if admin == "" {
+		admin = options.AdminUser
+	}
+
+	// The entitlements requests carry the partition being provisioned, the client is detached
+	// from a reloadable configuration so the partition id is not swapped back
+	client := a
+	client.osduSettings.PartitionId = partition_id
+	client.live = nil
+
+	steps := []struct {
+		name string
+		skip bool
+		run  func() error
+	}{
+		{ProvisionStepPartition, false, func() (err error) {
+			result.Upsert, err = client.UpsertPartition(p.Partition, strategy)
+			return err
+		}},
+		{ProvisionStepEntitlements, false, func() error {
+			return client.EntitlementsBootstrap()
+		}},
+		{ProvisionStepAdminUser, admin == "", func() error {
+			return client.EntitlementsCreateAdminUser(admin)
+		}},
+	}
+
+	failed := false
+	for _, step := range steps {
+		if failed || step.skip {
+			result.Steps = append(result.Steps, ProvisionStepResult{Step: step.name, Skipped: true})
+			continue
+		}
+		step_start := time.Now()
+		err := step.run()
+		step_result := ProvisionStepResult{Step: step.name, Duration: time.Since(step_start), err: err}
+		if err != nil {
+			step_result.Error = err.Error()
+			failed = true
+			a._logger().ErrorContext(ctx, fmt.Sprintf("Partition %s: %s failed: %s", partition_id, step.name, err))
+		}
+		result.Steps = append(result.Steps, step_result)
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,162 @@
+package osdu_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisionPartitions(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string][]string{}
+	var running, maxRunning atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		partition_id := r.Header.Get("data-partition-id")
+		if strings.HasPrefix(r.URL.Path, "/partitions/") {
+			partition_id = strings.TrimPrefix(r.URL.Path, "/partitions/")
+
+			// Hold the registration to observe the concurrency
+			n := running.Add(1)
+			for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+			}
+			time.Sleep(20 * time.Millisecond)
+			running.Add(-1)
+		}
+
+		mu.Lock()
+		calls[partition_id] = append(calls[partition_id], r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch {
+		case partition_id == "broken" && r.URL.Path == "/tenant-provisioning":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/partitions/"):
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	_, mockAuth := createMockClient(server.URL, server.URL)
+	client, err := osdu.New(
+		osdu.WithAuthProvider(mockAuth),
+		osdu.WithSettings(config.OsduSettings{
+			PartitionId:        "opendes",
+			PartitionUrl:       server.URL,
+			EntitlementsUrl:    server.URL,
+			EntitlementsDomain: "group",
+		}),
+		osdu.WithRetryPolicy(osdu.RetryPolicy{Attempts: 1}),
+	)
+	require.NoError(t, err)
+
+	var partitions []osdu.PartitionProvisioning
+	for _, id := range []string{"tenant-a", "tenant-b", "broken", "tenant-c", "tenant-d"} {
+		properties := models.PartitionProperties{}
+		properties.DataPartitionId.Value = id
+		partitions = append(partitions, osdu.PartitionProvisioning{Partition: models.Partition{Properties: properties}})
+	}
+	partitions[3].AdminUser = "admin@tenant-c"
+
+	results, err := client.ProvisionPartitions(partitions, osdu.ProvisionOptions{Workers: 2})
+	assert.ErrorContains(t, err, "partition broken: entitlements")
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	require.Len(t, results, 5)
+
+	for i, result := range results {
+		assert.Equal(t, partitions[i].Partition.Properties.DataPartitionId.Value, result.PartitionId)
+		assert.Equal(t, osdu.PartitionCreated, result.Upsert.Action)
+		require.Len(t, result.Steps, 3)
+		assert.Equal(t, osdu.ProvisionStepPartition, result.Steps[0].Step)
+		assert.Equal(t, osdu.ProvisionStepEntitlements, result.Steps[1].Step)
+		assert.Equal(t, osdu.ProvisionStepAdminUser, result.Steps[2].Step)
+	}
+
+	// The failure of a partition skips its remaining steps only
+	assert.True(t, results[2].Failed())
+	assert.NotEmpty(t, results[2].Steps[1].Error)
+	assert.True(t, results[2].Steps[2].Skipped)
+	assert.False(t, results[0].Failed())
+	assert.True(t, results[0].Steps[2].Skipped, "no admin user")
+
+	// Steps are ordered and carry the partition being provisioned
+	assert.Equal(t, []string{"POST /partitions/tenant-a", "POST /tenant-provisioning"}, calls["tenant-a"])
+	assert.Equal(t, []string{
+		"POST /partitions/tenant-c",
+		"POST /tenant-provisioning",
+		"POST /groups/users@tenant-c.group/members",
+		"POST /groups/users.datalake.ops@tenant-c.group/members",
+		"POST /groups/users.datalake.admins@tenant-c.group/members",
+	}, calls["tenant-c"])
+	assert.Empty(t, calls["opendes"])
+}
+
+// Run with -race: the workers share the provider of the client
+func TestProvisionPartitionsSharedProvider(t *testing.T) {
+	var token_requests atomic.Int32
+	token_server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token_requests.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"shared-access-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer token_server.Close()
+
+	var mu sync.Mutex
+	authorizations := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations[r.Header.Get("Authorization")] = true
+		mu.Unlock()
+		if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/partitions/") {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	provider := auth.NewOpenIDProvider(config.AuthSettings{
+		TokenUrl:  token_server.URL,
+		ClientId:  "provisioner",
+		GrantType: "client_credentials",
+		Scopes:    []string{"openid"},
+	})
+	client, err := osdu.New(
+		osdu.WithAuthProvider(provider),
+		osdu.WithSettings(config.OsduSettings{
+			PartitionId:        "opendes",
+			PartitionUrl:       server.URL,
+			EntitlementsUrl:    server.URL,
+			EntitlementsDomain: "group",
+		}),
+		osdu.WithRetryPolicy(osdu.RetryPolicy{Attempts: 1}),
+	)
+	require.NoError(t, err)
+
+	var partitions []osdu.PartitionProvisioning
+	for _, id := range []string{"tenant-a", "tenant-b", "tenant-c", "tenant-d", "tenant-e", "tenant-f"} {
+		properties := models.PartitionProperties{}
+		properties.DataPartitionId.Value = id
+		partitions = append(partitions, osdu.PartitionProvisioning{Partition: models.Partition{Properties: properties}})
+	}
+
+	results, err := client.ProvisionPartitions(partitions, osdu.ProvisionOptions{Workers: 4})
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	assert.Equal(t, int32(1), token_requests.Load(), "the workers wait for the token being fetched")
+	assert.Equal(t, map[string]bool{"Bearer shared-access-token": true}, authorizations)
+}
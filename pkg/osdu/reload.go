@@ -0,0 +1,212 @@
+package osdu
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+)
+
+// DefaultReloadInterval is how often a ConfigWatcher checks the configuration inputs
+const DefaultReloadInterval = 10 * time.Second
+
+// liveState is the part of a client replaced on reload
+type liveState struct {
+	osduSettings config.OsduSettings
+	authProvider auth.AuthProvider
+	internal     *config.InternalSettings
+}
+
+// liveConfig holds the current state of a reloadable client, swapped atomically
+type liveConfig struct {
+	state atomic.Pointer[liveState]
+}
+
+// _current returns the client with the settings and provider active right now.
+// Every request takes this snapshot first, a reload never changes a request in flight.
+func (a OsduApiRequest) _current() OsduApiRequest {
+	if a.live == nil {
+		return a
+	}
+	state := a.live.state.Load()
+	a.osduSettings = state.osduSettings
+	a.authProvider = state.authProvider
+	a.internal = state.internal
+	return a
+}
+
+// ReloadOptions configures a ConfigWatcher
+type ReloadOptions struct {
+	// Interval between two checks of the configuration file and secrets, DefaultReloadInterval when zero
+	Interval time.Duration
+	// OnReload is called after a new configuration has been swapped in
+	OnReload func(cfg config.Config)
+	// OnError is called when a changed configuration is rejected, the previous one stays active
+	OnError func(err error)
+	// ProviderFactory creates the auth provider of a configuration, the auth.ProviderFactory by default
+	ProviderFactory func(cfg config.OsduClient) (auth.AuthProvider, error)
+}
+
+// ConfigWatcher polls the inputs of a config.Loader (file, profile, OSDU_* variables and
+// referenced secret files) and swaps the settings and auth provider of a client when they change
+type ConfigWatcher struct {
+	loader  *config.Loader
+	live    *liveConfig
+	options ReloadOptions
+
+	mu       sync.Mutex
+	lastSeen string
+	// checking serializes Check, so concurrent polls reload once
+	checking sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewReloadableClient loads the configuration of loader and returns a client whose settings and
+// auth provider follow the configuration, with the watcher driving the reloads.
+// Call Start on the watcher to poll in the background, or Check to reload on demand.
+func NewReloadableClient(loader *config.Loader, options ReloadOptions) (OsduApiRequest, *ConfigWatcher, error) {
+	if options.Interval <= 0 {
+		options.Interval = DefaultReloadInterval
+	}
+	if options.ProviderFactory == nil {
+		options.ProviderFactory = auth.NewProviderFactory().GetProviderFromConfig
+	}
+
+	watcher := &ConfigWatcher{
+		loader:  loader,
+		live:    &liveConfig{},
+		options: options,
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return OsduApiRequest{}, nil, err
+	}
+	state, err := watcher._build_state(cfg)
+	if err != nil {
+		return OsduApiRequest{}, nil, err
+	}
+	watcher.live.state.Store(state)
+	watcher.lastSeen = loader.Fingerprint()
+
+	client := OsduApiRequest{live: watcher.live}
+	return client._current(), watcher, nil
+}
+
+// Start polls the configuration in the background until Stop is called
+func (w *ConfigWatcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(w.options.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				w.Check()
+			}
+		}
+	}(w.stop, w.done)
+}
+
+// Stop ends the background polling and waits for a running check to finish
+func (w *ConfigWatcher) Stop() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Check reloads the configuration when one of its inputs changed.
+// It reports whether a new configuration was swapped in; a rejected configuration is
+// returned as error, passed to OnError and the previous one stays active.
+func (w *ConfigWatcher) Check() (bool, error) {
+	w.checking.Lock()
+	defer w.checking.Unlock()
+
+	fingerprint := w.loader.Fingerprint()
+
+	w.mu.Lock()
+	if fingerprint == w.lastSeen {
+		w.mu.Unlock()
+		return false, nil
+	}
+	// A rejected change is reported once, not on every poll
+	w.lastSeen = fingerprint
+	w.mu.Unlock()
+
+	// The loader keeps serving the active configuration until the candidate is accepted
+	candidate, err := w.loader.Resolve()
+	if err == nil {
+		cfg := candidate.Config
+		var state *liveState
+		if state, err = w._build_state(cfg); err == nil {
+			w.loader.Commit(candidate)
+			w.live.state.Store(state)
+			// The candidate may track other secrets, its fingerprint is kept so the next
+			// poll does not reload the same configuration
+			w.mu.Lock()
+			w.lastSeen = candidate.Fingerprint()
+			w.mu.Unlock()
+
+			slog.Info(fmt.Sprintf("Configuration reloaded, partition %s", cfg.OsduClient.PartitionId))
+			if w.options.OnReload != nil {
+				w.options.OnReload(cfg)
+			}
+			return true, nil
+		}
+	}
+
+	w.loader.Reject(fingerprint)
+	err = fmt.Errorf("configuration reload rejected, keeping the active configuration: %w", err)
+	if w.options.OnError != nil {
+		w.options.OnError(err)
+	}
+	return false, err
+}
+
+// _build_state validates cfg and creates its auth provider
+func (w *ConfigWatcher) _build_state(cfg config.Config) (*liveState, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	provider, err := w.options.ProviderFactory(cfg.OsduClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth provider: %w", err)
+	}
+	if provider == nil {
+		return nil, errors.New("provider factory returned no provider")
+	}
+
+	state := &liveState{
+		osduSettings: cfg.OsduClient.OsduSettings,
+		authProvider: provider,
+	}
+	if cfg.OsduClient.InternalService {
+		internal := cfg.OsduClient.Internal
+		state.osduSettings = internal.ResolveUrls(state.osduSettings)
+		state.internal = &internal
+	}
+	return state, nil
+}
@@ -0,0 +1,370 @@
+package osdu_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/heba920908/osdu-sdk-go/pkg/auth"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
+	"github.com/heba920908/osdu-sdk-go/pkg/models"
+	"github.com/heba920908/osdu-sdk-go/pkg/osdu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticTokenProvider returns a token derived from the configured client secret
+type staticTokenProvider struct {
+	token string
+}
+
+func (p *staticTokenProvider) GetAccessToken(ctx context.Context) (*auth.Token, error) {
+	return &auth.Token{AccessToken: p.token, TokenType: "Bearer"}, nil
+}
+
+func (p *staticTokenProvider) IsTokenValid() bool { return true }
+
+func (p *staticTokenProvider) RefreshToken(ctx context.Context) (*auth.Token, error) {
+	return p.GetAccessToken(ctx)
+}
+
+func secretTokenFactory(cfg config.OsduClient) (auth.AuthProvider, error) {
+	return &staticTokenProvider{token: "token-" + cfg.ClientSecret}, nil
+}
+
+// recordingServer records the Authorization header of every request
+type recordingServer struct {
+	mu      sync.Mutex
+	headers []string
+}
+
+func (s *recordingServer) start(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.headers = append(s.headers, r.Header.Get("Authorization"))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *recordingServer) last() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.headers) == 0 {
+		return ""
+	}
+	return s.headers[len(s.headers)-1]
+}
+
+func writeReloadConfig(t *testing.T, configFile, clientSecret, entitlementsUrl string, mtime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(`
+osdu:
+  provider: openid
+  auth:
+    clientId: datafier
+    clientSecret: %s
+    tokenUrl: https://keycloak/realms/osdu/protocol/openid-connect/token
+  client:
+    partitionId: opendes
+    partitionUrl: https://osdu/api/partition/v1
+    entitlementsUrl: %s
+`, clientSecret, entitlementsUrl)), 0600))
+	require.NoError(t, os.Chtimes(configFile, mtime, mtime))
+}
+
+func TestReloadableClient_SwapAndReject(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	first, second := &recordingServer{}, &recordingServer{}
+	firstServer, secondServer := first.start(t), second.start(t)
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	start := time.Now().Add(-time.Hour)
+	writeReloadConfig(t, configFile, "secret-1", firstServer.URL, start)
+
+	var reloaded []config.Config
+	var rejected []error
+	client, watcher, err := osdu.NewReloadableClient(config.NewLoader().WithConfigFile(configFile), osdu.ReloadOptions{
+		OnReload:        func(cfg config.Config) { reloaded = append(reloaded, cfg) },
+		OnError:         func(err error) { rejected = append(rejected, err) },
+		ProviderFactory: secretTokenFactory,
+	})
+	require.NoError(t, err)
+	copied := client
+
+	require.NoError(t, client.EntitlementsBootstrap())
+	assert.Equal(t, "Bearer token-secret-1", first.last())
+
+	// Nothing changed
+	swapped, err := watcher.Check()
+	assert.False(t, swapped)
+	assert.NoError(t, err)
+
+	// Rotated secret and moved service
+	writeReloadConfig(t, configFile, "secret-2", secondServer.URL, start.Add(time.Minute))
+	swapped, err = watcher.Check()
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	require.Len(t, reloaded, 1)
+
+	require.NoError(t, copied.EntitlementsBootstrap())
+	assert.Equal(t, "Bearer token-secret-2", second.last())
+
+	// An invalid configuration keeps the active one
+	writeReloadConfig(t, configFile, "secret-3", "not-a-url", start.Add(2*time.Minute))
+	swapped, err = watcher.Check()
+	assert.False(t, swapped)
+	assert.ErrorContains(t, err, "osdu.client.entitlementsUrl")
+	require.Len(t, rejected, 1)
+
+	require.NoError(t, client.EntitlementsBootstrap())
+	assert.Equal(t, "Bearer token-secret-2", second.last())
+
+	// The rejected change is reported once
+	_, err = watcher.Check()
+	assert.NoError(t, err)
+	assert.Len(t, rejected, 1)
+}
+
+func TestReloadableClient_SecretFileRotation(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	server := &recordingServer{}
+	entitlementsServer := server.start(t)
+
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "client-secret")
+	start := time.Now().Add(-time.Hour)
+	require.NoError(t, os.WriteFile(secretFile, []byte("mounted-1\n"), 0600))
+	require.NoError(t, os.Chtimes(secretFile, start, start))
+
+	configFile := filepath.Join(dir, "config.yaml")
+	writeReloadConfig(t, configFile, "file:"+secretFile, entitlementsServer.URL, start)
+
+	reloads := make(chan config.Config, 1)
+	client, watcher, err := osdu.NewReloadableClient(config.NewLoader().WithConfigFile(configFile), osdu.ReloadOptions{
+		Interval:        10 * time.Millisecond,
+		OnReload:        func(cfg config.Config) { reloads <- cfg },
+		ProviderFactory: secretTokenFactory,
+	})
+	require.NoError(t, err)
+	watcher.Start()
+	defer watcher.Stop()
+
+	require.NoError(t, client.EntitlementsBootstrap())
+	assert.Equal(t, "Bearer token-mounted-1", server.last())
+
+	// Kubernetes updates the mounted secret, the config file is untouched
+	require.NoError(t, os.WriteFile(secretFile, []byte("mounted-2\n"), 0600))
+	require.NoError(t, os.Chtimes(secretFile, start.Add(time.Minute), start.Add(time.Minute)))
+
+	select {
+	case cfg := <-reloads:
+		assert.Equal(t, "mounted-2", cfg.OsduClient.ClientSecret)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+
+	require.NoError(t, client.EntitlementsBootstrap())
+	assert.Equal(t, "Bearer token-mounted-2", server.last())
+}
+
+func TestReloadableClient_NewSecretRefsReloadOnce(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	t.Setenv("TEST_RELOAD_CLIENT_SECRET", "from-env")
+	server := &recordingServer{}
+	entitlementsServer := server.start(t)
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	start := time.Now().Add(-time.Hour)
+	writeReloadConfig(t, configFile, "secret-1", entitlementsServer.URL, start)
+
+	var reloads int32
+	var mu sync.Mutex
+	_, watcher, err := osdu.NewReloadableClient(config.NewLoader().WithConfigFile(configFile), osdu.ReloadOptions{
+		OnReload: func(cfg config.Config) {
+			mu.Lock()
+			reloads++
+			mu.Unlock()
+		},
+		ProviderFactory: secretTokenFactory,
+	})
+	require.NoError(t, err)
+
+	// The new configuration references a secret the previous one did not track
+	writeReloadConfig(t, configFile, "env:TEST_RELOAD_CLIENT_SECRET", entitlementsServer.URL, start.Add(time.Minute))
+
+	// Concurrent polls swap the configuration once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := watcher.Check()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), reloads)
+
+	// The tracked secret is part of the fingerprint taken after the reload
+	swapped, err := watcher.Check()
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+	assert.Equal(t, int32(1), reloads)
+}
+
+func TestReloadableClient_ChangeRoleKeepsSnapshot(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	start := time.Now().Add(-time.Hour)
+
+	second := &recordingServer{}
+	secondServer := second.start(t)
+
+	var watcher *osdu.ConfigWatcher
+	var requests []string
+	firstServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.Header.Get("Authorization"))
+		if r.Method == http.MethodDelete {
+			// The configuration moves between the remove and the add
+			writeReloadConfig(t, configFile, "secret-2", secondServer.URL, start.Add(time.Minute))
+			swapped, err := watcher.Check()
+			assert.NoError(t, err)
+			assert.True(t, swapped)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer firstServer.Close()
+	writeReloadConfig(t, configFile, "secret-1", firstServer.URL, start)
+
+	client, watcher, err := osdu.NewReloadableClient(config.NewLoader().WithConfigFile(configFile), osdu.ReloadOptions{
+		ProviderFactory: secretTokenFactory,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.EntitlementsChangeRole("users.datalake.ops", "admin@opendes.com", "OWNER"))
+	assert.Equal(t, []string{"DELETE Bearer token-secret-1", "POST Bearer token-secret-1"}, requests)
+	assert.Empty(t, second.last())
+}
+
+func TestReloadableClient_ListGroupMembersKeepsSnapshot(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	start := time.Now().Add(-time.Hour)
+
+	second := &recordingServer{}
+	secondServer := second.start(t)
+
+	var watcher *osdu.ConfigWatcher
+	var requests []string
+	firstServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("cursor")+" "+r.Header.Get("Authorization"))
+		if r.URL.Query().Get("cursor") == "" {
+			// The configuration moves between the first and the second page
+			writeReloadConfig(t, configFile, "secret-2", secondServer.URL, start.Add(time.Minute))
+			swapped, err := watcher.Check()
+			assert.NoError(t, err)
+			assert.True(t, swapped)
+			w.Write([]byte(`{"members": [{"email": "a@opendes.com", "role": "MEMBER"}], "cursor": "page-2"}`))
+			return
+		}
+		w.Write([]byte(`{"members": [{"email": "b@opendes.com", "role": "OWNER"}]}`))
+	}))
+	defer firstServer.Close()
+	writeReloadConfig(t, configFile, "secret-1", firstServer.URL, start)
+
+	client, watcher, err := osdu.NewReloadableClient(config.NewLoader().WithConfigFile(configFile), osdu.ReloadOptions{
+		ProviderFactory: secretTokenFactory,
+	})
+	require.NoError(t, err)
+
+	members, err := client.ListGroupMembers("users.datalake.ops", models.EntitlementsListMembersOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, []string{" Bearer token-secret-1", "page-2 Bearer token-secret-1"}, requests)
+	assert.Empty(t, second.last())
+}
+
+func TestReloadableClient_ReconcileEntitlementsKeepsSnapshot(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	start := time.Now().Add(-time.Hour)
+
+	second := &recordingServer{}
+	secondServer := second.start(t)
+
+	fake := newFakeEntitlements()
+	var watcher *osdu.ConfigWatcher
+	var tokens []string
+	firstServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		if r.Method != http.MethodGet && len(fake.requests) == 0 {
+			// The configuration moves between the plan and the actions
+			writeReloadConfig(t, configFile, "secret-2", secondServer.URL, start.Add(time.Minute))
+			swapped, err := watcher.Check()
+			assert.NoError(t, err)
+			assert.True(t, swapped)
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer firstServer.Close()
+	writeReloadConfig(t, configFile, "secret-1", firstServer.URL, start)
+
+	client, watcher, err := osdu.NewReloadableClient(config.NewLoader().WithConfigFile(configFile), osdu.ReloadOptions{
+		ProviderFactory: secretTokenFactory,
+	})
+	require.NoError(t, err)
+
+	state, err := models.ParseEntitlementsState([]byte(entitlementsStateYaml))
+	require.NoError(t, err)
+	plan, err := client.ReconcileEntitlements(state, models.EntitlementsPlanOptions{})
+	require.NoError(t, err)
+	assert.Len(t, fake.requests, len(plan.Actions)+1, "the role change is a remove and an add")
+	for _, token := range tokens {
+		assert.Equal(t, "Bearer token-secret-1", token)
+	}
+	assert.Empty(t, second.last())
+}
+
+func TestReloadableClient_RejectedConfigNotLoaded(t *testing.T) {
+	t.Setenv(config.ProfileEnv, "")
+	server := (&recordingServer{}).start(t)
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	start := time.Now().Add(-time.Hour)
+	writeReloadConfig(t, configFile, "secret-1", server.URL, start)
+
+	loader := config.NewLoader().WithConfigFile(configFile)
+	_, watcher, err := osdu.NewReloadableClient(loader, osdu.ReloadOptions{ProviderFactory: secretTokenFactory})
+	require.NoError(t, err)
+
+	// The loader keeps serving the active configuration after a rejected reload
+	writeReloadConfig(t, configFile, "secret-2", "not-a-url", start.Add(time.Minute))
+	_, err = watcher.Check()
+	require.Error(t, err)
+
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, server.URL, cfg.OsduClient.EntitlementsUrl)
+	assert.Equal(t, "secret-1", cfg.OsduClient.ClientSecret)
+	assert.Equal(t, config.SourceFile, loader.Source("osdu.client.entitlementsUrl").Kind)
+
+	// A fixed configuration is loaded again
+	writeReloadConfig(t, configFile, "secret-3", server.URL, start.Add(2*time.Minute))
+	swapped, err := watcher.Check()
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	cfg, err = loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "secret-3", cfg.OsduClient.ClientSecret)
+}
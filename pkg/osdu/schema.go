@@ -4,16 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
 	"time"
 
 	retry "github.com/avast/retry-go"
+	"github.com/heba920908/osdu-sdk-go/pkg/config"
 )
 
 var api_schema_system_put = "schemas/system"
 
 func (a OsduApiRequest) PutSystemSchema(schemaPayload []byte) error {
+	a = a._current()
 	schema_url := fmt.Sprintf("%s/%s", a.osduSettings.SchemaUrl, api_schema_system_put)
 
 	var schema struct {
@@ -25,35 +26,35 @@ func (a OsduApiRequest) PutSystemSchema(schemaPayload []byte) error {
 	}
 
 	if err := json.Unmarshal(schemaPayload, &schema); err != nil {
-		slog.Warn(fmt.Sprintf("Failed to parse schema ID: %v", err))
+		a._logger().Warn(fmt.Sprintf("Failed to parse schema ID: %v", err))
 		schema.SchemaInfo.SchemaIdentity.ID = "unknown"
 	}
 
 	err := retry.Do(
 		func() error {
-			res, err := a.HttpRequestWithoutPartition("PUT", schema_url, schemaPayload)
+			res, err := a._http_request_without_partition(config.ServiceSchema, "PUT", schema_url, schemaPayload)
 			if err != nil {
 				return err
 			}
 
 			if res.StatusCode > http.StatusBadRequest {
 				if bodyBytes, err := io.ReadAll(res.Body); err == nil {
-					slog.Warn(string(bodyBytes))
+					a._logger().Warn(string(bodyBytes))
 				}
 				return fmt.Errorf("[%s] schema unexpected status code: %d", schema.SchemaInfo.SchemaIdentity.ID, res.StatusCode)
 			}
 
 			if res.StatusCode == http.StatusBadRequest {
-				slog.Warn(fmt.Sprintf("Schema %s most likely exists already", schema.SchemaInfo.SchemaIdentity.ID))
+				a._logger().Warn(fmt.Sprintf("Schema %s most likely exists already", schema.SchemaInfo.SchemaIdentity.ID))
 			}
 
-			slog.Info(fmt.Sprintf("DONE SchemaUpload %s StatusCode : %d", schema.SchemaInfo.SchemaIdentity.ID, res.StatusCode))
+			a._logger().Info(fmt.Sprintf("DONE SchemaUpload %s StatusCode : %d", schema.SchemaInfo.SchemaIdentity.ID, res.StatusCode))
 			return nil
 		},
-		retry.Attempts(3),
-		retry.Delay(5*time.Second),
+		a._retry_attempts(3),
+		a._retry_delay(5*time.Second),
 		retry.OnRetry(func(n uint, err error) {
-			slog.Warn(fmt.Sprintf("[%s] Schema Upload retry #%d: %s\n", schema.SchemaInfo.SchemaIdentity.ID, n, err))
+			a._logger().Warn(fmt.Sprintf("[%s] Schema Upload retry #%d: %s\n", schema.SchemaInfo.SchemaIdentity.ID, n, err))
 		}),
 	)
 